@@ -13,8 +13,10 @@ import (
 
 // Options is definition gosc's selection option.
 type Options struct {
-	Expression []string `short:"e" long:"expression" description:"execute given expression"`
-	DumpAST    bool     `short:"a" long:"ast" description:"whether leaf nodes are plotted"`
+	Expression     []string `short:"e" long:"expression" description:"execute given expression"`
+	DumpAST        bool     `short:"a" long:"ast" description:"whether leaf nodes are plotted"`
+	BacktraceDepth int      `short:"b" long:"backtrace" default:"20" description:"max depth of stack trace printed on error (0 disables it)"`
+	VM             bool     `long:"vm" description:"compile to bytecode and run on the virtual machine"`
 }
 
 func main() {
@@ -26,7 +28,7 @@ func main() {
 	if len(args) > 0 {
 		executeSourceCode(args[0], options)
 	} else if len(options.Expression) > 0 {
-		executeExpression(strings.Join(options.Expression, " "), options.DumpAST)
+		executeExpression(strings.Join(options.Expression, " "), options)
 	} else {
 		repl(options)
 	}
@@ -37,17 +39,31 @@ func executeSourceCode(filename string, options *Options) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	executeExpression(string(buffer), options.DumpAST)
+	interpreter := scheme.NewInterpreter(string(buffer))
+	interpreter.SetFilename(filename)
+	interpreter.SetBacktraceDepth(options.BacktraceDepth)
+	if options.VM {
+		interpreter.SetVM(true)
+	}
+	interpreter.PrintResult(options.DumpAST)
 }
 
-func executeExpression(expression string, dumpAST bool) {
+func executeExpression(expression string, options *Options) {
 	interpreter := scheme.NewInterpreter(expression)
-	interpreter.PrintResult(dumpAST)
+	interpreter.SetBacktraceDepth(options.BacktraceDepth)
+	if options.VM {
+		interpreter.SetVM(true)
+	}
+	interpreter.PrintResult(options.DumpAST)
 }
 
 func repl(options *Options) {
 	fmt.Println(">>> REPL of gosc is running...")
 	mainInterpreter := scheme.NewInterpreter("")
+	mainInterpreter.SetBacktraceDepth(options.BacktraceDepth)
+	if options.VM {
+		mainInterpreter.SetVM(true)
+	}
 
 	for {
 		indentLevel := 0
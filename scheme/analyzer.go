@@ -0,0 +1,373 @@
+// Analyzer resolves variables to lexical addresses before evaluation.
+// It walks the expanded AST with scopes, which are laid out in the same way
+// as frames created by binding forms such as lambda and let at runtime.
+// A variable bound in a scope gets the address (depth, index), where depth
+// is the number of frames to go up from the current frame, and index is
+// the position of the value in the frame. A free variable at the top level
+// gets the cell of the top level binding.
+// Variables which are not analyzed, such as ones in forms created by macros
+// at runtime, are looked up by their identifiers.
+
+package scheme
+
+// Analyzer is a struction for analysis of lexical addresses.
+type analyzer struct {
+	// The frame where the analyzed form is evaluated.
+	environment *Environment
+	scopes      []*scope
+}
+
+// Scope is a layout of a frame, which is created by the form.
+type scope struct {
+	form        Object
+	outside     Object // the part of the form which is not in the scope
+	identifiers []string
+}
+
+// Lexical address of a variable.
+type lexicalAddress struct {
+	depth int
+	index int
+}
+
+// Resolve variables in the object, which is evaluated in the environment.
+func analyze(object Object, environment *Environment) {
+	a := &analyzer{environment: environment}
+	a.analyze(object)
+}
+
+func (a *analyzer) analyze(object Object) {
+	switch object.(type) {
+	case *Variable:
+		a.resolve(object.(*Variable))
+	case *Application:
+		a.analyzeApplication(object.(*Application))
+	}
+}
+
+func (a *analyzer) analyzeApplication(application *Application) {
+	variable, ok := application.procedure.(*Variable)
+	if !ok {
+		a.analyze(application.procedure)
+		a.analyzeArguments(application, 0)
+		return
+	}
+
+	switch a.keyword(variable) {
+	case builtinSyntaxes["quote"], builtinSyntaxes["syntax-rules"]:
+	case builtinSyntaxes["quasiquote"]:
+		a.analyzeQuasiquote(argumentAt(application, 0), 1)
+	case builtinSyntaxes["lambda"]:
+		a.pushScope(application.arguments.Parent(), formIdentifiers(argumentAt(application, 0))...)
+		a.analyzeBody(application, 1)
+		application.frames = a.popScopes(1)
+	case builtinSyntaxes["let"]:
+		a.analyzeLet(application)
+	case builtinSyntaxes["let*"], builtinSyntaxes["let*-values"]:
+		a.analyzeLetStar(application)
+	case builtinSyntaxes["letrec"], builtinSyntaxes["letrec*"]:
+		a.pushScope(application.arguments.Parent(), a.bindingIdentifiers(application)...)
+		a.analyzeBindings(application)
+		a.analyzeBody(application, 1)
+		application.frames = a.popScopes(1)
+	case builtinSyntaxes["let-values"]:
+		a.analyzeBindings(application)
+		a.pushScope(application.arguments.Parent(), a.bindingIdentifiers(application)...)
+		a.analyzeBody(application, 1)
+		application.frames = a.popScopes(1)
+	case builtinSyntaxes["do"]:
+		a.analyzeDo(application)
+	case builtinSyntaxes["guard"]:
+		a.analyzeArguments(application, 1)
+		if clauses, ok := argumentAt(application, 0).(*Application); ok {
+			a.pushScope(application.arguments.Parent(), formIdentifiers(clauses.procedure)...)
+			a.analyzeArguments(clauses, 0)
+			application.frames = a.popScopes(1)
+		}
+	case builtinSyntaxes["let-syntax"]:
+		// Templates of transformers are outside of the scope of keywords.
+		a.pushScope(application.arguments.Parent(), a.bindingIdentifiers(application)...)
+		a.scopes[len(a.scopes)-1].outside = argumentAt(application, 0)
+		a.analyzeBody(application, 1)
+		application.frames = a.popScopes(1)
+	case builtinSyntaxes["letrec-syntax"]:
+		a.pushScope(application.arguments.Parent(), a.bindingIdentifiers(application)...)
+		a.analyzeBody(application, 1)
+		application.frames = a.popScopes(1)
+	case builtinSyntaxes["define"]:
+		a.declare(argumentAt(application, 0))
+		if _, ok := argumentAt(application, 0).(*Variable); ok {
+			a.analyzeArguments(application, 1)
+		}
+	case builtinSyntaxes["define-syntax"]:
+		a.declare(argumentAt(application, 0))
+	case builtinSyntaxes["define-macro"]:
+		a.declare(argumentAt(application, 0))
+		a.analyzeArguments(application, 1)
+	case builtinSyntaxes["define-record-type"]:
+		for _, variable := range recordDefinitions(application) {
+			a.declare(variable)
+		}
+	case builtinSyntaxes["set!"]:
+		a.analyzeArguments(application, 0)
+	default:
+		if _, ok := a.keyword(variable).(*Macro); ok {
+			// Forms in the macro use are analyzed when it is expanded at runtime.
+			return
+		}
+		a.resolve(variable)
+		a.analyzeArguments(application, 0)
+	}
+}
+
+// Returns the bound syntax or macro, or nil for local variables.
+func (a *analyzer) keyword(variable *Variable) Object {
+	for _, scope := range a.scopes {
+		if scope.indexOf(variable.identifier) >= 0 {
+			return nil
+		}
+	}
+	return a.environment.boundedObject(variable)
+}
+
+// Analyze arguments of the application from the index.
+func (a *analyzer) analyzeArguments(application *Application, from int) {
+	index := 0
+	for pair := application.arguments; pair.isPair(); pair = pair.(*Pair).Cdr {
+		if index >= from {
+			a.analyze(pair.(*Pair).Car)
+		}
+		index++
+	}
+}
+
+// Analyze the body from the index, whose definitions are declared first,
+// so that they can be referred from any expression in the body.
+func (a *analyzer) analyzeBody(application *Application, from int) {
+	index := 0
+	for pair := application.arguments; pair.isPair(); pair = pair.(*Pair).Cdr {
+		if index >= from {
+			a.declareDefinitions(pair.(*Pair).Car)
+		}
+		index++
+	}
+	a.analyzeArguments(application, from)
+}
+
+// Declare variables defined by the form, which may be in begin.
+func (a *analyzer) declareDefinitions(form Object) {
+	application, ok := form.(*Application)
+	if !ok || !application.procedure.isVariable() {
+		return
+	}
+	switch a.keyword(application.procedure.(*Variable)) {
+	case builtinSyntaxes["define"], builtinSyntaxes["define-syntax"], builtinSyntaxes["define-macro"]:
+		a.declare(argumentAt(application, 0))
+	case builtinSyntaxes["define-record-type"]:
+		for _, variable := range recordDefinitions(application) {
+			a.declare(variable)
+		}
+	case builtinSyntaxes["begin"]:
+		for pair := application.arguments; pair.isPair(); pair = pair.(*Pair).Cdr {
+			a.declareDefinitions(pair.(*Pair).Car)
+		}
+	}
+}
+
+// (let [name] ((variable init) ...) body ...)
+// Named let creates the frame of the name, and the frame of the variables
+// for each call.
+func (a *analyzer) analyzeLet(application *Application) {
+	a.analyzeBindings(application)
+	form := application.arguments.Parent()
+	count := 1
+	if name := argumentAt(application, 0); name != nil && name.isVariable() {
+		a.pushScope(form, name)
+		count = 2
+	}
+	a.pushScope(form, a.bindingIdentifiers(application)...)
+	a.analyzeBody(application, count)
+	application.frames = a.popScopes(count)
+}
+
+// (let* ((variable init) ...) body ...)
+// Each binding creates a frame, and the body is in the last frame.
+func (a *analyzer) analyzeLetStar(application *Application) {
+	form := application.arguments.Parent()
+	bindings, _ := formElements(argumentAt(application, 0))
+	for _, binding := range bindings {
+		if elements, ok := formElements(binding); ok && len(elements) == 2 {
+			a.analyze(elements[1])
+			a.pushScope(form, formIdentifiers(elements[0])...)
+		} else {
+			a.pushScope(form)
+		}
+	}
+	a.pushScope(form)
+	a.analyzeBody(application, 1)
+	application.frames = a.popScopes(len(bindings) + 1)
+}
+
+// (do ((variable init [step]) ...) (test expression ...) body ...)
+func (a *analyzer) analyzeDo(application *Application) {
+	bindings, _ := formElements(argumentAt(application, 0))
+	variables := []Object{}
+	for _, binding := range bindings {
+		if elements, ok := formElements(binding); ok && len(elements) > 1 {
+			variables = append(variables, elements[0])
+			a.analyze(elements[1])
+		}
+	}
+
+	a.pushScope(application.arguments.Parent(), variables...)
+	for _, binding := range bindings {
+		if elements, ok := formElements(binding); ok && len(elements) > 2 {
+			a.analyze(elements[2])
+		}
+	}
+	if test, ok := argumentAt(application, 1).(*Application); ok {
+		a.analyze(test.procedure)
+		a.analyzeArguments(test, 0)
+	}
+	a.analyzeBody(application, 2)
+	application.frames = a.popScopes(1)
+}
+
+// Analyze inits of ((variable init) ...) in the current scope.
+func (a *analyzer) analyzeBindings(application *Application) {
+	bindings := argumentAt(application, 0)
+	if bindings != nil && bindings.isVariable() {
+		bindings = argumentAt(application, 1)
+	}
+	elements, _ := formElements(bindings)
+	for _, binding := range elements {
+		if bindingElements, ok := formElements(binding); ok && len(bindingElements) == 2 {
+			a.analyze(bindingElements[1])
+		}
+	}
+}
+
+// Returns variables of ((variable init) ...), where variable may be
+// formals of let-values.
+func (a *analyzer) bindingIdentifiers(application *Application) []Object {
+	bindings := argumentAt(application, 0)
+	if bindings != nil && bindings.isVariable() {
+		bindings = argumentAt(application, 1)
+	}
+	variables := []Object{}
+	elements, _ := formElements(bindings)
+	for _, binding := range elements {
+		if bindingElements, ok := formElements(binding); ok && len(bindingElements) > 0 {
+			variables = append(variables, formIdentifiers(bindingElements[0])...)
+		}
+	}
+	return variables
+}
+
+// Analyze unquoted expressions in the template of quasiquote.
+func (a *analyzer) analyzeQuasiquote(template Object, depth int) {
+	switch template.(type) {
+	case *Application:
+		application := template.(*Application)
+		if isSyntaxApplication(application, "unquote") || isSyntaxApplication(application, "unquote-splicing") {
+			if depth == 1 {
+				a.analyzeArguments(application, 0)
+				return
+			}
+			depth--
+		} else if isSyntaxApplication(application, "quasiquote") {
+			depth++
+		}
+
+		a.analyzeQuasiquote(application.procedure, depth)
+		tail := application.arguments
+		for ; tail.isPair(); tail = tail.(*Pair).Cdr {
+			a.analyzeQuasiquote(tail.(*Pair).Car, depth)
+		}
+		a.analyzeQuasiquote(tail, depth)
+	case *Vector:
+		for _, element := range template.(*Vector).elements {
+			a.analyzeQuasiquote(element, depth)
+		}
+	}
+}
+
+// Resolve the variable to the lexical address in scopes, or the cell of
+// the top level binding. Renamed variable which is not bound refers to
+// the original variable, in the scope which encloses the macro template.
+func (a *analyzer) resolve(variable *Variable) {
+	for target := variable; target != nil; target = target.original {
+		for depth := 0; depth < len(a.scopes); depth++ {
+			scope := a.scopes[len(a.scopes)-1-depth]
+			frame := &Environment{scope: scope.form, outside: scope.outside}
+			if index := scope.indexOf(target.identifier); index >= 0 && (target == variable || frame.encloses(target)) {
+				variable.address = &lexicalAddress{depth: depth, index: index}
+				return
+			}
+		}
+	}
+	if variable.original == nil && a.environment.cells != nil {
+		variable.cell = a.environment.cell(variable.identifier)
+	}
+}
+
+func (a *analyzer) pushScope(form Object, variables ...Object) {
+	scope := &scope{form: form}
+	for _, variable := range variables {
+		if variable.isVariable() && scope.indexOf(variable.(*Variable).identifier) < 0 {
+			scope.identifiers = append(scope.identifiers, variable.(*Variable).identifier)
+		}
+	}
+	a.scopes = append(a.scopes, scope)
+}
+
+// Pop the count of scopes, and returns their identifiers from the outer one.
+func (a *analyzer) popScopes(count int) [][]string {
+	frames := [][]string{}
+	for _, scope := range a.scopes[len(a.scopes)-count:] {
+		frames = append(frames, scope.identifiers)
+	}
+	a.scopes = a.scopes[:len(a.scopes)-count]
+	return frames
+}
+
+// Declare the variable defined in the current scope.
+func (a *analyzer) declare(variable Object) {
+	if len(a.scopes) == 0 || variable == nil || !variable.isVariable() {
+		return
+	}
+	scope := a.scopes[len(a.scopes)-1]
+	if scope.indexOf(variable.(*Variable).identifier) < 0 {
+		scope.identifiers = append(scope.identifiers, variable.(*Variable).identifier)
+	}
+}
+
+func (s *scope) indexOf(identifier string) int {
+	for index, bound := range s.identifiers {
+		if bound == identifier {
+			return index
+		}
+	}
+	return -1
+}
+
+// Returns variables defined by define-record-type, which are the type name,
+// the constructor, the predicate, and the accessors and modifiers.
+func recordDefinitions(application *Application) []Object {
+	elements, _ := formElements(application)
+	if len(elements) < 4 {
+		return nil
+	}
+	elements = elements[1:]
+	variables := []Object{elements[0]}
+	if constructor, ok := formElements(elements[1]); ok && len(constructor) > 0 {
+		variables = append(variables, constructor[0])
+	}
+	variables = append(variables, elements[2])
+	for _, element := range elements[3:] {
+		if fieldSpec, ok := formElements(element); ok && len(fieldSpec) > 1 {
+			variables = append(variables, fieldSpec[1:]...)
+		}
+	}
+	return variables
+}
@@ -9,6 +9,8 @@ type Application struct {
 	ObjectBase
 	procedure Object
 	arguments Object
+	frames    [][]string // identifiers of frames created by this form, which are laid out by analysis
+	datum     *Pair      // the list which this form is converted from, or nil
 }
 
 type Invoker interface {
@@ -23,19 +25,20 @@ func NewApplication(parent Object) *Application {
 }
 
 // Eval is eval function IF that returns applyProcedure.
+// Applications left in tail position are resolved here in a loop,
+// so that tail calls run in constant Go stack space.
 func (a *Application) Eval() Object {
-	return a.applyProcedure()
+	return trampoline(a.applyProcedure())
 }
 
 func (a *Application) String() string {
-	// Exceptional handling for special form: quote
-	if a.procedure.isVariable() {
-		variable := a.procedure.(*Variable)
-		if variable.boundedObject(variable.identifier) == builtinSyntaxes["quote"] {
-			if a.arguments.isNull() {
-				return "(quote)"
+	// Exceptional handling for special forms: quote, quasiquote, unquote and unquote-splicing
+	for abbreviation, keyword := range abbreviations {
+		if isSyntaxApplication(a, keyword) {
+			if !a.arguments.isPair() {
+				return "(" + keyword + ")"
 			} else {
-				return "'" + a.arguments.(*Pair).ElementAt(0).String()
+				return abbreviation + a.arguments.(*Pair).Car.String()
 			}
 		}
 	}
@@ -55,14 +58,17 @@ func (a *Application) toList() *Pair {
 }
 
 func (a *Application) applyProcedure() Object {
+	running.callStack = append(running.callStack, Frame{application: a})
+	defer func() { running.callStack = running.callStack[:len(running.callStack)-1] }()
+
 	evaledObject := a.procedure.Eval()
+	running.callStack[len(running.callStack)-1].bounder = evaledObject.Bounder()
 
 	switch evaledObject.(type) {
 	case Invoker:
 		return evaledObject.(Invoker).Invoke(a.arguments)
 	default:
-		runtimeError("invalid application")
-		return nil
+		return raise(&TypeError{newErrorBase("", evaledObject, "invalid application")}, false)
 	}
 }
 
@@ -14,12 +14,12 @@ func NewBoolean(value interface{}, options ...Object) (boolean *Boolean) {
 	case bool:
 		boolean = &Boolean{value: value.(bool)}
 	case string:
-		if value == "#t" {
+		if value == "#t" || value == "#true" {
 			boolean = &Boolean{value: true}
-		} else if value == "#f" {
+		} else if value == "#f" || value == "#false" {
 			boolean = &Boolean{value: false}
 		} else {
-			compileError("Unexpected value for NewBoolean")
+			runtimeError("Unexpected value for NewBoolean")
 		}
 	default:
 		return nil
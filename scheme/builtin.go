@@ -3,51 +3,225 @@
 package scheme
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"os"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // NewSubroutines has some symbols builtined.
 var (
 	builtinProcedure = Binding{
-		"+":              NewSubroutine(plusProc),
-		"-":              NewSubroutine(minusProc),
-		"*":              NewSubroutine(multiplyProc),
-		"/":              NewSubroutine(divideProc),
-		"=":              NewSubroutine(equalProc),
-		"<":              NewSubroutine(lessThanProc),
-		"<=":             NewSubroutine(lessEqualProc),
-		">":              NewSubroutine(greaterThanProc),
-		">=":             NewSubroutine(greaterEqualProc),
-		"append":         NewSubroutine(appendProc),
-		"boolean?":       NewSubroutine(isBooleanProc),
-		"car":            NewSubroutine(carProc),
-		"cdr":            NewSubroutine(cdrProc),
-		"cons":           NewSubroutine(consProc),
-		"eq?":            NewSubroutine(isEqProc),
-		"equal?":         NewSubroutine(isEqualProc),
-		"last":           NewSubroutine(lastProc),
-		"length":         NewSubroutine(lengthProc),
-		"list":           NewSubroutine(listProc),
-		"list?":          NewSubroutine(isListProc),
-		"load":           NewSubroutine(loadProc),
-		"memq":           NewSubroutine(memqProc),
-		"neq?":           NewSubroutine(isNeqProc),
-		"number?":        NewSubroutine(isNumberProc),
-		"number->string": NewSubroutine(numberToStringProc),
-		"pair?":          NewSubroutine(isPairProc),
-		"print":          NewSubroutine(printProc),
-		"procedure?":     NewSubroutine(isProcedureProc),
-		"set-car!":       NewSubroutine(setCarProc),
-		"set-cdr!":       NewSubroutine(setCdrProc),
-		"string?":        NewSubroutine(isStringProc),
-		"string-append":  NewSubroutine(stringAppendProc),
-		"string->number": NewSubroutine(stringToNumberProc),
-		"symbol->string": NewSubroutine(symbolToStringProc),
-		"string->symbol": NewSubroutine(stringToSymbolProc),
-		"symbol?":        NewSubroutine(isSymbolProc),
-		"write":          NewSubroutine(writeProc),
+		"+":                              NewSubroutine(plusProc),
+		"-":                              NewSubroutine(minusProc),
+		"*":                              NewSubroutine(multiplyProc),
+		"/":                              NewSubroutine(divideProc),
+		"=":                              NewSubroutine(equalProc),
+		"<":                              NewSubroutine(lessThanProc),
+		"<=":                             NewSubroutine(lessEqualProc),
+		">":                              NewSubroutine(greaterThanProc),
+		">=":                             NewSubroutine(greaterEqualProc),
+		"abs":                            NewSubroutine(absProc),
+		"acos":                           NewSubroutine(acosProc),
+		"append":                         NewSubroutine(appendProc),
+		"asin":                           NewSubroutine(asinProc),
+		"atan":                           NewSubroutine(atanProc),
+		"boolean?":                       NewSubroutine(isBooleanProc),
+		"bytevector":                     NewSubroutine(bytevectorProc),
+		"bytevector-length":              NewSubroutine(bytevectorLengthProc),
+		"bytevector-u8-ref":              NewSubroutine(bytevectorU8RefProc),
+		"bytevector-u8-set!":             NewSubroutine(bytevectorU8SetProc),
+		"bytevector?":                    NewSubroutine(isBytevectorProc),
+		"call-with-input-file":           NewSubroutine(callWithInputFileProc),
+		"call-with-output-file":          NewSubroutine(callWithOutputFileProc),
+		"call-with-output-string":        NewSubroutine(callWithOutputStringProc),
+		"call-with-values":               NewSubroutine(callWithValuesProc),
+		"call/cc":                        NewSubroutine(callCCProc),
+		"call-with-current-continuation": NewSubroutine(callCCProc),
+		"car":                            NewSubroutine(carProc),
+		"cdr":                            NewSubroutine(cdrProc),
+		"ceiling":                        NewSubroutine(ceilingProc),
+		"char->integer":                  NewSubroutine(charToIntegerProc),
+		"char-alphabetic?":               NewSubroutine(isCharAlphabeticProc),
+		"char-ci<=?":                     NewSubroutine(charCiLessEqualProc),
+		"char-ci<?":                      NewSubroutine(charCiLessThanProc),
+		"char-ci=?":                      NewSubroutine(charCiEqualProc),
+		"char-ci>=?":                     NewSubroutine(charCiGreaterEqualProc),
+		"char-ci>?":                      NewSubroutine(charCiGreaterThanProc),
+		"char-downcase":                  NewSubroutine(charDowncaseProc),
+		"char-foldcase":                  NewSubroutine(charFoldcaseProc),
+		"char-lower-case?":               NewSubroutine(isCharLowerCaseProc),
+		"char-numeric?":                  NewSubroutine(isCharNumericProc),
+		"char-ready?":                    NewSubroutine(isCharReadyProc),
+		"char-upcase":                    NewSubroutine(charUpcaseProc),
+		"char-upper-case?":               NewSubroutine(isCharUpperCaseProc),
+		"char-whitespace?":               NewSubroutine(isCharWhitespaceProc),
+		"char<=?":                        NewSubroutine(charLessEqualProc),
+		"char<?":                         NewSubroutine(charLessThanProc),
+		"char=?":                         NewSubroutine(charEqualProc),
+		"char>=?":                        NewSubroutine(charGreaterEqualProc),
+		"char>?":                         NewSubroutine(charGreaterThanProc),
+		"char?":                          NewSubroutine(isCharProc),
+		"close-port":                     NewSubroutine(closePortProc),
+		"cons":                           NewSubroutine(consProc),
+		"cos":                            NewSubroutine(cosProc),
+		"current-error-port":             NewSubroutine(currentErrorPortProc),
+		"current-input-port":             NewSubroutine(currentInputPortProc),
+		"current-output-port":            NewSubroutine(currentOutputPortProc),
+		"delete-file":                    NewSubroutine(deleteFileProc),
+		"digit-value":                    NewSubroutine(digitValueProc),
+		"directory-list":                 NewSubroutine(directoryListProc),
+		"display":                        NewSubroutine(displayProc),
+		"dynamic-wind":                   NewSubroutine(dynamicWindProc),
+		"eof-object":                     NewSubroutine(eofObjectProc),
+		"eof-object?":                    NewSubroutine(isEOFObjectProc),
+		"eq?":                            NewSubroutine(isEqProc),
+		"equal?":                         NewSubroutine(isEqualProc),
+		"eqv?":                           NewSubroutine(isEqProc),
+		"error":                          NewSubroutine(errorProc),
+		"error-object?":                  NewSubroutine(isErrorObjectProc),
+		"error-object-message":           NewSubroutine(errorObjectMessageProc),
+		"error-object-irritants":         NewSubroutine(errorObjectIrritantsProc),
+		"eval":                           NewSubroutine(evalProc),
+		"even?":                          NewSubroutine(isEvenProc),
+		"exact":                          NewSubroutine(exactProc),
+		"exact-integer-sqrt":             NewSubroutine(exactIntegerSqrtProc),
+		"exact?":                         NewSubroutine(isExactProc),
+		"exp":                            NewSubroutine(expProc),
+		"expt":                           NewSubroutine(exptProc),
+		"file-exists?":                   NewSubroutine(fileExistsProc),
+		"floor":                          NewSubroutine(floorProc),
+		"floor/":                         NewSubroutine(floorDivideProc),
+		"gcd":                            NewSubroutine(gcdProc),
+		"gensym":                         NewSubroutine(gensymProc),
+		"get-output-string":              NewSubroutine(getOutputStringProc),
+		"hash-table->alist":              NewSubroutine(hashTableToAlistProc),
+		"hash-table-contains?":           NewSubroutine(hashTableContainsProc),
+		"hash-table-count":               NewSubroutine(hashTableCountProc),
+		"hash-table-delete!":             NewSubroutine(hashTableDeleteProc),
+		"hash-table-exists?":             NewSubroutine(hashTableContainsProc),
+		"hash-table-keys":                NewSubroutine(hashTableKeysProc),
+		"hash-table-ref":                 NewSubroutine(hashTableRefProc),
+		"hash-table-ref/default":         NewSubroutine(hashTableRefDefaultProc),
+		"hash-table-set!":                NewSubroutine(hashTableSetProc),
+		"hash-table-update!":             NewSubroutine(hashTableUpdateProc),
+		"hash-table-update!/default":     NewSubroutine(hashTableUpdateDefaultProc),
+		"hash-table-values":              NewSubroutine(hashTableValuesProc),
+		"hash-table-walk":                NewSubroutine(hashTableWalkProc),
+		"hash-table?":                    NewSubroutine(isHashTableProc),
+		"inexact":                        NewSubroutine(inexactProc),
+		"inexact?":                       NewSubroutine(isInexactProc),
+		"input-port?":                    NewSubroutine(isInputPortProc),
+		"integer->char":                  NewSubroutine(integerToCharProc),
+		"integer?":                       NewSubroutine(isIntegerProc),
+		"last":                           NewSubroutine(lastProc),
+		"lcm":                            NewSubroutine(lcmProc),
+		"length":                         NewSubroutine(lengthProc),
+		"list":                           NewSubroutine(listProc),
+		"list->string":                   NewSubroutine(listToStringProc),
+		"list->vector":                   NewSubroutine(listToVectorProc),
+		"list?":                          NewSubroutine(isListProc),
+		"load":                           NewSubroutine(loadProc),
+		"log":                            NewSubroutine(logProc),
+		"macroexpand":                    NewSubroutine(macroexpandProc),
+		"macroexpand-1":                  NewSubroutine(macroexpand1Proc),
+		"make-bytevector":                NewSubroutine(makeBytevectorProc),
+		"make-directory":                 NewSubroutine(makeDirectoryProc),
+		"make-hash-table":                NewSubroutine(makeHashTableProc),
+		"make-string":                    NewSubroutine(makeStringProc),
+		"make-vector":                    NewSubroutine(makeVectorProc),
+		"max":                            NewSubroutine(maxProc),
+		"memq":                           NewSubroutine(memqProc),
+		"min":                            NewSubroutine(minProc),
+		"modulo":                         NewSubroutine(moduloProc),
+		"negative?":                      NewSubroutine(isNegativeProc),
+		"neq?":                           NewSubroutine(isNeqProc),
+		"newline":                        NewSubroutine(newlineProc),
+		"number?":                        NewSubroutine(isNumberProc),
+		"number->string":                 NewSubroutine(numberToStringProc),
+		"odd?":                           NewSubroutine(isOddProc),
+		"open-input-file":                NewSubroutine(openInputFileProc),
+		"open-input-string":              NewSubroutine(openInputStringProc),
+		"open-output-file":               NewSubroutine(openOutputFileProc),
+		"open-output-string":             NewSubroutine(openOutputStringProc),
+		"output-port?":                   NewSubroutine(isOutputPortProc),
+		"pair?":                          NewSubroutine(isPairProc),
+		"peek-char":                      NewSubroutine(peekCharProc),
+		"positive?":                      NewSubroutine(isPositiveProc),
+		"port?":                          NewSubroutine(isPortProc),
+		"print":                          NewSubroutine(printProc),
+		"procedure?":                     NewSubroutine(isProcedureProc),
+		"quotient":                       NewSubroutine(quotientProc),
+		"raise":                          NewSubroutine(raiseProc),
+		"raise-continuable":              NewSubroutine(raiseContinuableProc),
+		"read":                           NewSubroutine(readProc),
+		"read-char":                      NewSubroutine(readCharProc),
+		"read-error?":                    NewSubroutine(isReadErrorProc),
+		"read-line":                      NewSubroutine(readLineProc),
+		"read-string":                    NewSubroutine(readStringProc),
+		"remainder":                      NewSubroutine(remainderProc),
+		"rename-file":                    NewSubroutine(renameFileProc),
+		"round":                          NewSubroutine(roundProc),
+		"set-car!":                       NewSubroutine(setCarProc),
+		"set-cdr!":                       NewSubroutine(setCdrProc),
+		"sin":                            NewSubroutine(sinProc),
+		"sqrt":                           NewSubroutine(sqrtProc),
+		"string":                         NewSubroutine(stringProc),
+		"string->list":                   NewSubroutine(stringToListProc),
+		"string-ci<=?":                   NewSubroutine(stringCiLessEqualProc),
+		"string-ci<?":                    NewSubroutine(stringCiLessThanProc),
+		"string-ci=?":                    NewSubroutine(stringCiEqualProc),
+		"string-ci>=?":                   NewSubroutine(stringCiGreaterEqualProc),
+		"string-ci>?":                    NewSubroutine(stringCiGreaterThanProc),
+		"string-copy":                    NewSubroutine(stringCopyProc),
+		"string-downcase":                NewSubroutine(stringDowncaseProc),
+		"string-fill!":                   NewSubroutine(stringFillProc),
+		"string-foldcase":                NewSubroutine(stringFoldcaseProc),
+		"string-index":                   NewSubroutine(stringIndexProc),
+		"string-join":                    NewSubroutine(stringJoinProc),
+		"string-length":                  NewSubroutine(stringLengthProc),
+		"string-ref":                     NewSubroutine(stringRefProc),
+		"string-set!":                    NewSubroutine(stringSetProc),
+		"string-split":                   NewSubroutine(stringSplitProc),
+		"string-upcase":                  NewSubroutine(stringUpcaseProc),
+		"string<=?":                      NewSubroutine(stringLessEqualProc),
+		"string<?":                       NewSubroutine(stringLessThanProc),
+		"string=?":                       NewSubroutine(stringEqualProc),
+		"string>=?":                      NewSubroutine(stringGreaterEqualProc),
+		"string>?":                       NewSubroutine(stringGreaterThanProc),
+		"string?":                        NewSubroutine(isStringProc),
+		"string-append":                  NewSubroutine(stringAppendProc),
+		"string->number":                 NewSubroutine(stringToNumberProc),
+		"substring":                      NewSubroutine(stringCopyProc),
+		"symbol->string":                 NewSubroutine(symbolToStringProc),
+		"string->symbol":                 NewSubroutine(stringToSymbolProc),
+		"symbol?":                        NewSubroutine(isSymbolProc),
+		"tan":                            NewSubroutine(tanProc),
+		"truncate":                       NewSubroutine(truncateProc),
+		"truncate/":                      NewSubroutine(truncateDivideProc),
+		"values":                         NewSubroutine(valuesProc),
+		"vector":                         NewSubroutine(vectorProc),
+		"vector->list":                   NewSubroutine(vectorToListProc),
+		"vector-copy":                    NewSubroutine(vectorCopyProc),
+		"vector-fill!":                   NewSubroutine(vectorFillProc),
+		"vector-for-each":                NewSubroutine(vectorForEachProc),
+		"vector-length":                  NewSubroutine(vectorLengthProc),
+		"vector-map":                     NewSubroutine(vectorMapProc),
+		"vector-ref":                     NewSubroutine(vectorRefProc),
+		"vector-set!":                    NewSubroutine(vectorSetProc),
+		"vector?":                        NewSubroutine(isVectorProc),
+		"with-exception-handler":         NewSubroutine(withExceptionHandlerProc),
+		"with-input-from-file":           NewSubroutine(withInputFromFileProc),
+		"with-output-to-file":            NewSubroutine(withOutputToFileProc),
+		"with-output-to-string":          NewSubroutine(withOutputToStringProc),
+		"write":                          NewSubroutine(writeProc),
+		"write-char":                     NewSubroutine(writeCharProc),
+		"write-string":                   NewSubroutine(writeStringProc),
+		"zero?":                          NewSubroutine(isZeroProc),
 	}
 )
 
@@ -64,21 +238,29 @@ func DefaultBinding() Binding {
 
 func assertListMinimum(arguments Object, minimum int) {
 	if !arguments.isList() {
-		compileError("proper list required for function application or macro use")
+		typeError(arguments, "proper list required for function application or macro use")
 	} else if arguments.(*Pair).ListLength() < minimum {
-		compileError("procedure requires at least %d argument", minimum)
+		arityError(arguments, "procedure requires at least %d argument", minimum)
 	}
 }
 
 func assertListEqual(arguments Object, length int) {
 	if !arguments.isList() {
-		compileError("proper list required for function application or macro use")
+		typeError(arguments, "proper list required for function application or macro use")
 	} else if arguments.(*Pair).ListLength() != length {
-		compileError("wrong number of arguments: requires %d, but got %d",
+		arityError(arguments, "wrong number of arguments: requires %d, but got %d",
 			length, arguments.(*Pair).ListLength())
 	}
 }
 
+func assertListRange(arguments Object, minimum int, maximum int) {
+	assertListMinimum(arguments, minimum)
+	if arguments.(*Pair).ListLength() > maximum {
+		arityError(arguments, "wrong number of arguments: requires at most %d, but got %d",
+			maximum, arguments.(*Pair).ListLength())
+	}
+}
+
 func assertObjectsType(objects []Object, typeName string) {
 	for _, object := range objects {
 		assertObjectType(object, typeName)
@@ -87,12 +269,23 @@ func assertObjectsType(objects []Object, typeName string) {
 
 func typeName(object Object) string {
 	switch object.(type) {
+	// Frequent types are named without formatting.
+	case *Number:
+		return "number"
+	case *String:
+		return "string"
+	case *Symbol:
+		return "symbol"
+	case *Char:
+		return "char"
 	case *Pair:
 		if object.isNull() {
 			return "null"
 		} else {
 			return "pair"
 		}
+	case *HashTable:
+		return "hash-table"
 	default:
 		rawTypeName := fmt.Sprintf("%T", object)
 		typeName := strings.Replace(rawTypeName, "*scheme.", "", 1)
@@ -102,12 +295,21 @@ func typeName(object Object) string {
 
 func assertObjectType(object Object, assertType string) {
 	if assertType != typeName(object) {
-		compileError("%s required, but got %s", assertType, object)
+		typeError(object, "%s required, but got %s", assertType, object)
+	}
+}
+
+func assertObjectsInteger(objects []Object) {
+	for _, object := range objects {
+		assertObjectType(object, "number")
+		if !object.(*Number).isInteger() {
+			typeError(object, "integer required, but got %s", object)
+		}
 	}
 }
 
 func evaledObjects(objects []Object) []Object {
-	evaledObjects := []Object{}
+	evaledObjects := make([]Object, 0, len(objects))
 
 	for _, object := range objects {
 		evaledObjects = append(evaledObjects, object.Eval())
@@ -115,6 +317,14 @@ func evaledObjects(objects []Object) []Object {
 	return evaledObjects
 }
 
+// Apply the procedure to objects which are already evaluated.
+func applyProcedure(procedure Object, objects ...Object) Object {
+	if !procedure.isProcedure() {
+		typeError(procedure, "procedure required, but got %s", procedure)
+	}
+	return trampoline(procedure.(Invoker).Invoke(NewList(nil, objects...)))
+}
+
 func booleanByFunc(arguments Object, typeCheckFunc func(Object) bool) Object {
 	assertListEqual(arguments, 1)
 
@@ -122,18 +332,19 @@ func booleanByFunc(arguments Object, typeCheckFunc func(Object) bool) Object {
 	return NewBoolean(typeCheckFunc(object))
 }
 
-func compareNumbers(arguments Object, compareFunc func(int, int) bool) Object {
+// NaN is not comparable, so any comparison with NaN returns #f.
+func compareNumbers(arguments Object, compareFunc func(int) bool) Object {
 	assertListMinimum(arguments, 2)
 
 	numbers := evaledObjects(arguments.(*Pair).Elements())
 	assertObjectsType(numbers, "number")
 
-	oldValue := numbers[0].(*Number).value
+	oldNumber := numbers[0].(*Number)
 	for _, number := range numbers[1:] {
-		if !compareFunc(oldValue, number.(*Number).value) {
+		if oldNumber.isNaN() || number.(*Number).isNaN() || !compareFunc(oldNumber.compare(number.(*Number))) {
 			return NewBoolean(false)
 		}
-		oldValue = number.(*Number).value
+		oldNumber = number.(*Number)
 	}
 	return NewBoolean(true)
 }
@@ -144,11 +355,11 @@ func plusProc(arguments Object) Object {
 	numbers := evaledObjects(arguments.(*Pair).Elements())
 	assertObjectsType(numbers, "number")
 
-	sum := 0
+	sum := NewNumber(0)
 	for _, number := range numbers {
-		sum += number.(*Number).value
+		sum = sum.add(number.(*Number))
 	}
-	return NewNumber(sum)
+	return sum
 }
 
 func minusProc(arguments Object) Object {
@@ -157,11 +368,11 @@ func minusProc(arguments Object) Object {
 	numbers := evaledObjects(arguments.(*Pair).Elements())
 	assertObjectsType(numbers, "number")
 
-	difference := numbers[0].(*Number).value
+	difference := numbers[0].(*Number)
 	for _, number := range numbers[1:] {
-		difference -= number.(*Number).value
+		difference = difference.subtract(number.(*Number))
 	}
-	return NewNumber(difference)
+	return difference
 }
 
 func multiplyProc(arguments Object) Object {
@@ -170,11 +381,11 @@ func multiplyProc(arguments Object) Object {
 	numbers := evaledObjects(arguments.(*Pair).Elements())
 	assertObjectsType(numbers, "number")
 
-	product := 1
+	product := NewNumber(1)
 	for _, number := range numbers {
-		product *= number.(*Number).value
+		product = product.multiply(number.(*Number))
 	}
-	return NewNumber(product)
+	return product
 }
 
 func divideProc(arguments Object) Object {
@@ -183,31 +394,281 @@ func divideProc(arguments Object) Object {
 	numbers := evaledObjects(arguments.(*Pair).Elements())
 	assertObjectsType(numbers, "number")
 
-	quotient := numbers[0].(*Number).value
+	quotient := numbers[0].(*Number)
 	for _, number := range numbers[1:] {
-		quotient /= number.(*Number).value
+		quotient = quotient.divide(number.(*Number))
 	}
-	return NewNumber(quotient)
+	return quotient
 }
 
 func equalProc(arguments Object) Object {
-	return compareNumbers(arguments, func(a, b int) bool { return a == b })
+	return compareNumbers(arguments, func(c int) bool { return c == 0 })
 }
 
 func lessThanProc(arguments Object) Object {
-	return compareNumbers(arguments, func(a, b int) bool { return a < b })
+	return compareNumbers(arguments, func(c int) bool { return c < 0 })
 }
 
 func lessEqualProc(arguments Object) Object {
-	return compareNumbers(arguments, func(a, b int) bool { return a <= b })
+	return compareNumbers(arguments, func(c int) bool { return c <= 0 })
 }
 
 func greaterThanProc(arguments Object) Object {
-	return compareNumbers(arguments, func(a, b int) bool { return a > b })
+	return compareNumbers(arguments, func(c int) bool { return c > 0 })
 }
 
 func greaterEqualProc(arguments Object) Object {
-	return compareNumbers(arguments, func(a, b int) bool { return a >= b })
+	return compareNumbers(arguments, func(c int) bool { return c >= 0 })
+}
+
+func numberByFunc(arguments Object, numberFunc func(*Number) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "number")
+	return numberFunc(object.(*Number))
+}
+
+func integerByFunc(arguments Object, integerFunc func(*Number) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectsInteger([]Object{object})
+	return integerFunc(object.(*Number))
+}
+
+// Transcendental functions always return inexact number.
+func floatByFunc(arguments Object, floatFunc func(float64) float64) Object {
+	return numberByFunc(arguments, func(number *Number) Object {
+		return NewNumber(floatFunc(number.float()))
+	})
+}
+
+// Returns the number which is selected by compareFunc.
+// The result is inexact when any argument is inexact.
+func selectNumber(arguments Object, compareFunc func(int) bool) Object {
+	assertListMinimum(arguments, 1)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(numbers, "number")
+
+	selected, exact := numbers[0].(*Number), numbers[0].(*Number).isExact()
+	for _, number := range numbers[1:] {
+		exact = exact && number.(*Number).isExact()
+		if compareFunc(number.(*Number).compare(selected)) {
+			selected = number.(*Number)
+		}
+	}
+	if !exact {
+		return selected.toInexact()
+	}
+	return selected
+}
+
+func divideIntegers(arguments Object, floor bool) (*Number, *Number) {
+	assertListEqual(arguments, 2)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsInteger(numbers)
+	return numbers[0].(*Number).divideInteger(numbers[1].(*Number), floor)
+}
+
+func quotientProc(arguments Object) Object {
+	quotient, _ := divideIntegers(arguments, false)
+	return quotient
+}
+
+func remainderProc(arguments Object) Object {
+	_, remainder := divideIntegers(arguments, false)
+	return remainder
+}
+
+func moduloProc(arguments Object) Object {
+	_, modulo := divideIntegers(arguments, true)
+	return modulo
+}
+
+func floorDivideProc(arguments Object) Object {
+	quotient, remainder := divideIntegers(arguments, true)
+	return NewValues(quotient, remainder)
+}
+
+func truncateDivideProc(arguments Object) Object {
+	quotient, remainder := divideIntegers(arguments, false)
+	return NewValues(quotient, remainder)
+}
+
+func absProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.abs() })
+}
+
+func minProc(arguments Object) Object {
+	return selectNumber(arguments, func(c int) bool { return c < 0 })
+}
+
+func maxProc(arguments Object) Object {
+	return selectNumber(arguments, func(c int) bool { return c > 0 })
+}
+
+func gcdProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsInteger(numbers)
+
+	divisor := NewNumber(0)
+	for _, number := range numbers {
+		divisor = divisor.gcd(number.(*Number))
+	}
+	return divisor
+}
+
+func lcmProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsInteger(numbers)
+
+	multiple := NewNumber(1)
+	for _, number := range numbers {
+		multiple = multiple.lcm(number.(*Number))
+	}
+	return multiple
+}
+
+func exptProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(numbers, "number")
+	return numbers[0].(*Number).expt(numbers[1].(*Number))
+}
+
+func exactIntegerSqrtProc(arguments Object) Object {
+	return integerByFunc(arguments, func(number *Number) Object {
+		if !number.isExact() || number.sign() < 0 {
+			typeError(number, "exact nonnegative integer required, but got %s", number)
+		}
+		return NewValues(number.exactIntegerSqrt())
+	})
+}
+
+func sqrtProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.sqrt() })
+}
+
+func expProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Exp)
+}
+
+// (log z1 z2) returns the logarithm of z1 base z2.
+func logProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(numbers, "number")
+	if len(numbers) == 2 {
+		return NewNumber(math.Log(numbers[0].(*Number).float()) / math.Log(numbers[1].(*Number).float()))
+	}
+	return NewNumber(math.Log(numbers[0].(*Number).float()))
+}
+
+func sinProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Sin)
+}
+
+func cosProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Cos)
+}
+
+func tanProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Tan)
+}
+
+func asinProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Asin)
+}
+
+func acosProc(arguments Object) Object {
+	return floatByFunc(arguments, math.Acos)
+}
+
+// (atan y x) returns the angle of the point (x, y).
+func atanProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	numbers := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(numbers, "number")
+	if len(numbers) == 2 {
+		return NewNumber(math.Atan2(numbers[0].(*Number).float(), numbers[1].(*Number).float()))
+	}
+	return NewNumber(math.Atan(numbers[0].(*Number).float()))
+}
+
+func floorProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.round(roundFloor) })
+}
+
+func ceilingProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.round(roundCeiling) })
+}
+
+func roundProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.round(roundRound) })
+}
+
+func truncateProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.round(roundTruncate) })
+}
+
+func exactProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.toExact() })
+}
+
+func inexactProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return number.toInexact() })
+}
+
+func isExactProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return NewBoolean(number.isExact()) })
+}
+
+func isInexactProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return NewBoolean(!number.isExact()) })
+}
+
+func isZeroProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(!number.isNaN() && number.sign() == 0)
+	})
+}
+
+func isPositiveProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return NewBoolean(number.sign() > 0) })
+}
+
+func isNegativeProc(arguments Object) Object {
+	return numberByFunc(arguments, func(number *Number) Object { return NewBoolean(number.sign() < 0) })
+}
+
+func isOddProc(arguments Object) Object {
+	return integerByFunc(arguments, func(number *Number) Object {
+		_, remainder := number.divideInteger(NewNumber(2), false)
+		return NewBoolean(remainder.sign() != 0)
+	})
+}
+
+func isEvenProc(arguments Object) Object {
+	return integerByFunc(arguments, func(number *Number) Object {
+		_, remainder := number.divideInteger(NewNumber(2), false)
+		return NewBoolean(remainder.sign() == 0)
+	})
+}
+
+func isIntegerProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber() && object.(*Number).isInteger()
+	})
 }
 
 func isNumberProc(arguments Object) Object {
@@ -238,6 +699,149 @@ func isStringProc(arguments Object) Object {
 	return booleanByFunc(arguments, func(object Object) bool { return object.isString() })
 }
 
+func isCharProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool { return object.isChar() })
+}
+
+func charByFunc(arguments Object, charFunc func(rune) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "char")
+	return charFunc(object.(*Char).value)
+}
+
+// Characters are compared by code point, and by folded case
+// when foldCase is true.
+func compareChars(arguments Object, foldCase bool, compareFunc func(rune, rune) bool) Object {
+	assertListMinimum(arguments, 2)
+
+	chars := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(chars, "char")
+
+	values := []rune{}
+	for _, char := range chars {
+		if foldCase {
+			values = append(values, foldRune(char.(*Char).value))
+		} else {
+			values = append(values, char.(*Char).value)
+		}
+	}
+	for i := 1; i < len(values); i++ {
+		if !compareFunc(values[i-1], values[i]) {
+			return NewBoolean(false)
+		}
+	}
+	return NewBoolean(true)
+}
+
+// Returns the simple case folding of the rune, which is
+// the lower case in most scripts.
+func foldRune(value rune) rune {
+	return unicode.ToLower(unicode.ToUpper(value))
+}
+
+func charToIntegerProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewNumber(int(value)) })
+}
+
+func integerToCharProc(arguments Object) Object {
+	return integerByFunc(arguments, func(number *Number) Object {
+		if number.kind != fixnumKind || number.value > unicode.MaxRune || !utf8.ValidRune(rune(number.value)) {
+			typeError(number, "valid unicode code point required, but got %s", number)
+		}
+		return NewChar(rune(number.value))
+	})
+}
+
+func charEqualProc(arguments Object) Object {
+	return compareChars(arguments, false, func(a, b rune) bool { return a == b })
+}
+
+func charLessThanProc(arguments Object) Object {
+	return compareChars(arguments, false, func(a, b rune) bool { return a < b })
+}
+
+func charGreaterThanProc(arguments Object) Object {
+	return compareChars(arguments, false, func(a, b rune) bool { return a > b })
+}
+
+func charLessEqualProc(arguments Object) Object {
+	return compareChars(arguments, false, func(a, b rune) bool { return a <= b })
+}
+
+func charGreaterEqualProc(arguments Object) Object {
+	return compareChars(arguments, false, func(a, b rune) bool { return a >= b })
+}
+
+func charCiEqualProc(arguments Object) Object {
+	return compareChars(arguments, true, func(a, b rune) bool { return a == b })
+}
+
+func charCiLessThanProc(arguments Object) Object {
+	return compareChars(arguments, true, func(a, b rune) bool { return a < b })
+}
+
+func charCiGreaterThanProc(arguments Object) Object {
+	return compareChars(arguments, true, func(a, b rune) bool { return a > b })
+}
+
+func charCiLessEqualProc(arguments Object) Object {
+	return compareChars(arguments, true, func(a, b rune) bool { return a <= b })
+}
+
+func charCiGreaterEqualProc(arguments Object) Object {
+	return compareChars(arguments, true, func(a, b rune) bool { return a >= b })
+}
+
+func charUpcaseProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewChar(unicode.ToUpper(value)) })
+}
+
+func charDowncaseProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewChar(unicode.ToLower(value)) })
+}
+
+func charFoldcaseProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewChar(foldRune(value)) })
+}
+
+func isCharAlphabeticProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewBoolean(unicode.IsLetter(value)) })
+}
+
+func isCharNumericProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewBoolean(unicode.IsDigit(value)) })
+}
+
+func isCharWhitespaceProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewBoolean(unicode.IsSpace(value)) })
+}
+
+func isCharUpperCaseProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewBoolean(unicode.IsUpper(value)) })
+}
+
+func isCharLowerCaseProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object { return NewBoolean(unicode.IsLower(value)) })
+}
+
+// Returns the digit value of a decimal digit character in any script,
+// such as 3 for #\x0663 (ARABIC-INDIC DIGIT THREE), or #f.
+func digitValueProc(arguments Object) Object {
+	return charByFunc(arguments, func(value rune) Object {
+		if !unicode.IsDigit(value) {
+			return NewBoolean(false)
+		}
+		// Decimal digits are encoded in runs of 10 consecutive code points from zero.
+		zero := value
+		for unicode.IsDigit(zero - 1) {
+			zero--
+		}
+		return NewNumber(int(value-zero) % 10)
+	})
+}
+
 func consProc(arguments Object) Object {
 	assertListEqual(arguments, 2)
 	objects := evaledObjects(arguments.(*Pair).Elements())
@@ -266,7 +870,8 @@ func cdrProc(arguments Object) Object {
 }
 
 func listProc(arguments Object) Object {
-	return arguments
+	assertListMinimum(arguments, 0)
+	return NewList(arguments.Parent(), evaledObjects(arguments.(*Pair).Elements())...)
 }
 
 func setCarProc(arguments Object) Object {
@@ -328,7 +933,7 @@ func lastProc(arguments Object) Object {
 
 	list := arguments.(*Pair).ElementAt(0).Eval()
 	if !list.isPair() {
-		runtimeError("pair required: %s", list)
+		raise(&TypeError{newErrorBase("", list, "pair required: %s", list)}, false)
 	}
 	assertListMinimum(list, 1)
 
@@ -361,57 +966,866 @@ func stringAppendProc(arguments Object) Object {
 	return NewString(strings.Join(texts, ""))
 }
 
-func symbolToStringProc(arguments Object) Object {
-	assertListEqual(arguments, 1)
+// Returns the index which is given as argument of procedures for sequence.
+// The index must not be greater than the length.
+func indexArgument(object Object, length int) int {
+	assertObjectType(object, "number")
+	if index := object.(*Number); index.kind != fixnumKind || index.value < 0 {
+		typeError(object, "index required, but got %s", object)
+	} else if index.value > length {
+		runtimeError("index out of range: %d", index.value)
+	}
+	return object.(*Number).value
+}
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	assertObjectType(object, "symbol")
-	return NewString(object.(*Symbol).identifier)
+// Returns optional start and end indices, such as (string-copy s start end).
+func rangeArguments(objects []Object, length int) (int, int) {
+	start, end := 0, length
+	if len(objects) > 0 {
+		start = indexArgument(objects[0], length)
+	}
+	if len(objects) > 1 {
+		end = indexArgument(objects[1], length)
+	}
+	if start > end {
+		runtimeError("start index %d is greater than end index %d", start, end)
+	}
+	return start, end
 }
 
-func stringToSymbolProc(arguments Object) Object {
+func stringByFunc(arguments Object, stringFunc func(string) Object) Object {
 	assertListEqual(arguments, 1)
 
 	object := arguments.(*Pair).ElementAt(0).Eval()
 	assertObjectType(object, "string")
-	return NewSymbol(object.(*String).text)
+	return stringFunc(object.(*String).text)
 }
 
-func stringToNumberProc(arguments Object) Object {
-	assertListEqual(arguments, 1)
+// Strings are compared by code point, and by folded case
+// when foldCase is true.
+func compareStrings(arguments Object, foldCase bool, compareFunc func(int) bool) Object {
+	assertListMinimum(arguments, 2)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	assertObjectType(object, "string")
-	return NewNumber(object.(*String).text)
+	stringObjects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(stringObjects, "string")
+
+	texts := []string{}
+	for _, stringObject := range stringObjects {
+		if foldCase {
+			texts = append(texts, strings.Map(foldRune, stringObject.(*String).text))
+		} else {
+			texts = append(texts, stringObject.(*String).text)
+		}
+	}
+	for i := 1; i < len(texts); i++ {
+		if !compareFunc(strings.Compare(texts[i-1], texts[i])) {
+			return NewBoolean(false)
+		}
+	}
+	return NewBoolean(true)
 }
 
-func numberToStringProc(arguments Object) Object {
-	assertListEqual(arguments, 1)
+func stringProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	assertObjectType(object, "number")
-	return NewString(object.(*Number).value)
-}
+	chars := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(chars, "char")
 
-func areIdentical(a Object, b Object) bool {
-	if typeName(a) != typeName(b) {
-		return false
+	runes := []rune{}
+	for _, char := range chars {
+		runes = append(runes, char.(*Char).value)
 	}
+	return NewString(string(runes))
+}
 
-	switch a.(type) {
-	case *Number:
-		return a.(*Number).value == b.(*Number).value
-	case *Boolean:
-		return a.(*Boolean).value == b.(*Boolean).value
-	default:
-		return a == b
+func makeStringProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	length := indexArgument(objects[0], math.MaxInt32)
+	fill := ' '
+	if len(objects) == 2 {
+		assertObjectType(objects[1], "char")
+		fill = objects[1].(*Char).value
 	}
+	return NewString(strings.Repeat(string(fill), length))
 }
 
-func areEqual(a Object, b Object) bool {
-	if a == nil {
-		return true
-	}
+func stringLengthProc(arguments Object) Object {
+	return stringByFunc(arguments, func(text string) Object {
+		return NewNumber(utf8.RuneCountInString(text))
+	})
+}
+
+func stringRefProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	runes := []rune(objects[0].(*String).text)
+	index := indexArgument(objects[1], len(runes))
+	if index == len(runes) {
+		runtimeError("index out of range: %d", index)
+	}
+	return NewChar(runes[index])
+}
+
+func stringSetProc(arguments Object) Object {
+	assertListEqual(arguments, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	assertObjectType(objects[2], "char")
+	runes := []rune(objects[0].(*String).text)
+	index := indexArgument(objects[1], len(runes))
+	if index == len(runes) {
+		runtimeError("index out of range: %d", index)
+	}
+
+	runes[index] = objects[2].(*Char).value
+	objects[0].(*String).text = string(runes)
+	return undef
+}
+
+func stringFillProc(arguments Object) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	assertObjectType(objects[1], "char")
+	runes := []rune(objects[0].(*String).text)
+	start, end := rangeArguments(objects[2:], len(runes))
+
+	for i := start; i < end; i++ {
+		runes[i] = objects[1].(*Char).value
+	}
+	objects[0].(*String).text = string(runes)
+	return undef
+}
+
+// (substring s start end) is the same as (string-copy s start end).
+func stringCopyProc(arguments Object) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	runes := []rune(objects[0].(*String).text)
+	start, end := rangeArguments(objects[1:], len(runes))
+	return NewString(string(runes[start:end]))
+}
+
+func stringEqualProc(arguments Object) Object {
+	return compareStrings(arguments, false, func(c int) bool { return c == 0 })
+}
+
+func stringLessThanProc(arguments Object) Object {
+	return compareStrings(arguments, false, func(c int) bool { return c < 0 })
+}
+
+func stringGreaterThanProc(arguments Object) Object {
+	return compareStrings(arguments, false, func(c int) bool { return c > 0 })
+}
+
+func stringLessEqualProc(arguments Object) Object {
+	return compareStrings(arguments, false, func(c int) bool { return c <= 0 })
+}
+
+func stringGreaterEqualProc(arguments Object) Object {
+	return compareStrings(arguments, false, func(c int) bool { return c >= 0 })
+}
+
+func stringCiEqualProc(arguments Object) Object {
+	return compareStrings(arguments, true, func(c int) bool { return c == 0 })
+}
+
+func stringCiLessThanProc(arguments Object) Object {
+	return compareStrings(arguments, true, func(c int) bool { return c < 0 })
+}
+
+func stringCiGreaterThanProc(arguments Object) Object {
+	return compareStrings(arguments, true, func(c int) bool { return c > 0 })
+}
+
+func stringCiLessEqualProc(arguments Object) Object {
+	return compareStrings(arguments, true, func(c int) bool { return c <= 0 })
+}
+
+func stringCiGreaterEqualProc(arguments Object) Object {
+	return compareStrings(arguments, true, func(c int) bool { return c >= 0 })
+}
+
+func stringUpcaseProc(arguments Object) Object {
+	return stringByFunc(arguments, func(text string) Object { return NewString(strings.ToUpper(text)) })
+}
+
+func stringDowncaseProc(arguments Object) Object {
+	return stringByFunc(arguments, func(text string) Object { return NewString(strings.ToLower(text)) })
+}
+
+func stringFoldcaseProc(arguments Object) Object {
+	return stringByFunc(arguments, func(text string) Object { return NewString(strings.Map(foldRune, text)) })
+}
+
+func stringToListProc(arguments Object) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	runes := []rune(objects[0].(*String).text)
+	start, end := rangeArguments(objects[1:], len(runes))
+
+	chars := []Object{}
+	for _, char := range runes[start:end] {
+		chars = append(chars, NewChar(char))
+	}
+	return NewList(nil, chars...)
+}
+
+func listToStringProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	list := arguments.(*Pair).ElementAt(0).Eval()
+	assertListMinimum(list, 0)
+	chars := list.(*Pair).Elements()
+	assertObjectsType(chars, "char")
+
+	runes := []rune{}
+	for _, char := range chars {
+		runes = append(runes, char.(*Char).value)
+	}
+	return NewString(string(runes))
+}
+
+// Returns a function which matches a character, by a char or a predicate.
+func charMatcher(object Object) func(rune) bool {
+	if object.isChar() {
+		return func(char rune) bool { return char == object.(*Char).value }
+	} else if !object.isProcedure() {
+		typeError(object, "char or procedure required, but got %s", object)
+	}
+	return func(char rune) bool {
+		result := applyProcedure(object, NewChar(char))
+		return !result.isBoolean() || result.(*Boolean).value
+	}
+}
+
+// (string-index s pred [start end]) returns the index of the first character
+// which matches pred, or #f. pred is a char or a predicate.
+func stringIndexProc(arguments Object) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	runes := []rune(objects[0].(*String).text)
+	matchFunc := charMatcher(objects[1])
+	start, end := rangeArguments(objects[2:], len(runes))
+
+	for i := start; i < end; i++ {
+		if matchFunc(runes[i]) {
+			return NewNumber(i)
+		}
+	}
+	return NewBoolean(false)
+}
+
+// (string-split s delimiter) splits s by delimiter, which is
+// a char, a string or a predicate.
+func stringSplitProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+
+	fields := []string{}
+	if objects[1].isString() {
+		if objects[1].(*String).text == "" {
+			runtimeError("delimiter must not be empty string")
+		}
+		fields = strings.Split(objects[0].(*String).text, objects[1].(*String).text)
+	} else {
+		matchFunc := charMatcher(objects[1])
+		field := []rune{}
+		for _, char := range objects[0].(*String).text {
+			if matchFunc(char) {
+				fields = append(fields, string(field))
+				field = []rune{}
+			} else {
+				field = append(field, char)
+			}
+		}
+		fields = append(fields, string(field))
+	}
+
+	stringObjects := []Object{}
+	for _, field := range fields {
+		stringObjects = append(stringObjects, NewString(field))
+	}
+	return NewList(nil, stringObjects...)
+}
+
+// (string-join list [delimiter [grammar]]) joins strings by delimiter,
+// which is " " by default. grammar is one of infix, strict-infix,
+// prefix and suffix.
+func stringJoinProc(arguments Object) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertListMinimum(objects[0], 0)
+	stringObjects := objects[0].(*Pair).Elements()
+	assertObjectsType(stringObjects, "string")
+
+	delimiter, grammar := " ", "infix"
+	if len(objects) > 1 {
+		assertObjectType(objects[1], "string")
+		delimiter = objects[1].(*String).text
+	}
+	if len(objects) > 2 {
+		assertObjectType(objects[2], "symbol")
+		grammar = objects[2].(*Symbol).identifier
+	}
+
+	texts := []string{}
+	for _, stringObject := range stringObjects {
+		texts = append(texts, stringObject.(*String).text)
+	}
+	text := strings.Join(texts, delimiter)
+	switch grammar {
+	case "infix":
+		return NewString(text)
+	case "strict-infix":
+		if len(texts) == 0 {
+			runtimeError("string-join: list must not be empty with strict-infix grammar")
+		}
+		return NewString(text)
+	case "prefix":
+		if len(texts) == 0 {
+			return NewString("")
+		}
+		return NewString(delimiter + text)
+	case "suffix":
+		if len(texts) == 0 {
+			return NewString("")
+		}
+		return NewString(text + delimiter)
+	default:
+		typeError(objects[2], "one of infix, strict-infix, prefix and suffix required, but got %s", objects[2])
+		return nil
+	}
+}
+
+func isVectorProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool { return object.isVector() })
+}
+
+func vectorProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+	return NewVector(evaledObjects(arguments.(*Pair).Elements()))
+}
+
+func makeVectorProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	length := indexArgument(objects[0], math.MaxInt32)
+	var fill Object = undef
+	if len(objects) == 2 {
+		fill = objects[1]
+	}
+
+	elements := make([]Object, length)
+	for i := range elements {
+		elements[i] = fill
+	}
+	return NewVector(elements)
+}
+
+func vectorLengthProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "vector")
+	return NewNumber(len(object.(*Vector).elements))
+}
+
+func vectorRefProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	index := indexArgument(objects[1], len(elements))
+	if index == len(elements) {
+		runtimeError("index out of range: %d", index)
+	}
+	return elements[index]
+}
+
+func vectorSetProc(arguments Object) Object {
+	assertListEqual(arguments, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	index := indexArgument(objects[1], len(elements))
+	if index == len(elements) {
+		runtimeError("index out of range: %d", index)
+	}
+
+	elements[index] = objects[2]
+	return undef
+}
+
+func vectorToListProc(arguments Object) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[1:], len(elements))
+	return NewList(nil, elements[start:end]...)
+}
+
+func listToVectorProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	list := arguments.(*Pair).ElementAt(0).Eval()
+	assertListMinimum(list, 0)
+	return NewVector(list.(*Pair).Elements())
+}
+
+func vectorCopyProc(arguments Object) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[1:], len(elements))
+	return NewVector(append([]Object{}, elements[start:end]...))
+}
+
+func vectorFillProc(arguments Object) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[2:], len(elements))
+
+	for i := start; i < end; i++ {
+		elements[i] = objects[1]
+	}
+	return undef
+}
+
+// Applies the procedure to elements of vectors at each index,
+// up to the length of the shortest vector.
+func mapVectors(arguments Object, mapFunc func(int, Object)) {
+	assertListMinimum(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	vectors := objects[1:]
+	assertObjectsType(vectors, "vector")
+
+	length := len(vectors[0].(*Vector).elements)
+	for _, vector := range vectors[1:] {
+		if len(vector.(*Vector).elements) < length {
+			length = len(vector.(*Vector).elements)
+		}
+	}
+	for i := 0; i < length; i++ {
+		elements := []Object{}
+		for _, vector := range vectors {
+			elements = append(elements, vector.(*Vector).elements[i])
+		}
+		mapFunc(i, applyProcedure(objects[0], elements...))
+	}
+}
+
+func vectorMapProc(arguments Object) Object {
+	elements := []Object{}
+	mapVectors(arguments, func(_ int, result Object) {
+		elements = append(elements, result)
+	})
+	return NewVector(elements)
+}
+
+func vectorForEachProc(arguments Object) Object {
+	mapVectors(arguments, func(int, Object) {})
+	return undef
+}
+
+func isBytevectorProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*Bytevector)
+		return ok
+	})
+}
+
+func bytevectorProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+
+	bytes := []byte{}
+	for _, object := range evaledObjects(arguments.(*Pair).Elements()) {
+		bytes = append(bytes, byteValue(object))
+	}
+	return NewBytevector(bytes)
+}
+
+func makeBytevectorProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	length := indexArgument(objects[0], math.MaxInt32)
+	fill := byte(0)
+	if len(objects) == 2 {
+		fill = byteValue(objects[1])
+	}
+
+	bytes := make([]byte, length)
+	for i := range bytes {
+		bytes[i] = fill
+	}
+	return NewBytevector(bytes)
+}
+
+func bytevectorLengthProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "bytevector")
+	return NewNumber(len(object.(*Bytevector).bytes))
+}
+
+func bytevectorU8RefProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "bytevector")
+	bytes := objects[0].(*Bytevector).bytes
+	index := indexArgument(objects[1], len(bytes))
+	if index == len(bytes) {
+		runtimeError("index out of range: %d", index)
+	}
+	return NewNumber(int(bytes[index]))
+}
+
+func bytevectorU8SetProc(arguments Object) Object {
+	assertListEqual(arguments, 3)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "bytevector")
+	bytes := objects[0].(*Bytevector).bytes
+	index := indexArgument(objects[1], len(bytes))
+	if index == len(bytes) {
+		runtimeError("index out of range: %d", index)
+	}
+
+	bytes[index] = byteValue(objects[2])
+	return undef
+}
+
+func isHashTableProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*HashTable)
+		return ok
+	})
+}
+
+// Comparator is given as an equality procedure such as equal?,
+// or its name such as 'equal?. The default comparator is equal?.
+func makeHashTableProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	if len(objects) == 0 {
+		return NewHashTable("equal?")
+	}
+
+	comparator, ok := hashTableComparators[objects[0]]
+	if objects[0].isSymbol() {
+		for _, name := range hashTableComparators {
+			if name == objects[0].(*Symbol).identifier {
+				comparator, ok = name, true
+			}
+		}
+	}
+	if !ok {
+		typeError(objects[0], "one of eq?, eqv?, equal? and string=? required, but got %s", objects[0])
+	}
+	return NewHashTable(comparator)
+}
+
+// Evaluates arguments whose first element is a hash table.
+func hashTableArguments(arguments Object, minimum int, maximum int) (*HashTable, []Object) {
+	assertListRange(arguments, minimum, maximum)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "hash-table")
+	return objects[0].(*HashTable), objects[1:]
+}
+
+// Returns the value of the key, which is passed to success procedure if given.
+// When the key is not found, failure thunk is called.
+func refHashTable(table *HashTable, key Object, procedures []Object) Object {
+	value, ok := table.lookup(key)
+	if !ok && len(procedures) == 0 {
+		runtimeError("key not found: %s", key)
+	} else if !ok {
+		return applyProcedure(procedures[0])
+	} else if len(procedures) == 2 {
+		return applyProcedure(procedures[1], value)
+	}
+	return value
+}
+
+// (hash-table-ref table key [failure [success]])
+func hashTableRefProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 2, 4)
+	return refHashTable(table, objects[0], objects[1:])
+}
+
+func hashTableRefDefaultProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 3, 3)
+	if value, ok := table.lookup(objects[0]); ok {
+		return value
+	}
+	return objects[1]
+}
+
+// (hash-table-set! table key value ...) sets each pair of key and value.
+func hashTableSetProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 1, math.MaxInt32)
+	if len(objects)%2 != 0 {
+		arityError(arguments, "hash-table-set! requires pairs of key and value")
+	}
+	for i := 0; i < len(objects); i += 2 {
+		table.store(objects[i], objects[i+1])
+	}
+	return undef
+}
+
+// (hash-table-delete! table key ...) returns the number of deleted keys.
+func hashTableDeleteProc(arguments Object) Object {
+	table, keys := hashTableArguments(arguments, 1, math.MaxInt32)
+	count := 0
+	for _, key := range keys {
+		if table.remove(key) {
+			count++
+		}
+	}
+	return NewNumber(count)
+}
+
+func hashTableContainsProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 2, 2)
+	_, ok := table.lookup(objects[0])
+	return NewBoolean(ok)
+}
+
+// (hash-table-update! table key updater [failure [success]]) sets the result
+// of updater, which is called with the value by hash-table-ref.
+func hashTableUpdateProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 3, 5)
+	value := refHashTable(table, objects[0], objects[2:])
+	table.store(objects[0], applyProcedure(objects[1], value))
+	return undef
+}
+
+func hashTableUpdateDefaultProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 4, 4)
+	value, ok := table.lookup(objects[0])
+	if !ok {
+		value = objects[2]
+	}
+	table.store(objects[0], applyProcedure(objects[1], value))
+	return undef
+}
+
+func hashTableKeysProc(arguments Object) Object {
+	table, _ := hashTableArguments(arguments, 1, 1)
+	keys := []Object{}
+	for _, entry := range table.sortedEntries() {
+		keys = append(keys, entry.key)
+	}
+	return NewList(nil, keys...)
+}
+
+func hashTableValuesProc(arguments Object) Object {
+	table, _ := hashTableArguments(arguments, 1, 1)
+	values := []Object{}
+	for _, entry := range table.sortedEntries() {
+		values = append(values, entry.value)
+	}
+	return NewList(nil, values...)
+}
+
+func hashTableToAlistProc(arguments Object) Object {
+	table, _ := hashTableArguments(arguments, 1, 1)
+	pairs := []Object{}
+	for _, entry := range table.sortedEntries() {
+		pairs = append(pairs, &Pair{Car: entry.key, Cdr: entry.value})
+	}
+	return NewList(nil, pairs...)
+}
+
+// (hash-table-walk table procedure) calls procedure with each key and value.
+func hashTableWalkProc(arguments Object) Object {
+	table, objects := hashTableArguments(arguments, 2, 2)
+	for _, entry := range table.sortedEntries() {
+		applyProcedure(objects[0], entry.key, entry.value)
+	}
+	return undef
+}
+
+// (hash-table-count table) returns the number of associations, and
+// (hash-table-count predicate table) returns the number of associations
+// which satisfy the predicate called with key and value.
+func hashTableCountProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[len(objects)-1], "hash-table")
+	table := objects[len(objects)-1].(*HashTable)
+	if len(objects) == 1 {
+		return NewNumber(len(table.entries))
+	}
+
+	count := 0
+	for _, entry := range table.sortedEntries() {
+		result := applyProcedure(objects[0], entry.key, entry.value)
+		if !result.isBoolean() || result.(*Boolean).value {
+			count++
+		}
+	}
+	return NewNumber(count)
+}
+
+func symbolToStringProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "symbol")
+	return NewString(object.(*Symbol).identifier)
+}
+
+func stringToSymbolProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "string")
+	return NewSymbol(object.(*String).text)
+}
+
+// (gensym [prefix]) returns a new symbol which is not eq? to any other symbol.
+func gensymProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	prefix := "G"
+	if arguments.(*Pair).ListLength() == 1 {
+		object := arguments.(*Pair).ElementAt(0).Eval()
+		if object.isSymbol() {
+			prefix = object.String()
+		} else {
+			assertObjectType(object, "string")
+			prefix = object.(*String).text
+		}
+	}
+	return NewGensym(prefix)
+}
+
+// Evaluate the datum as an expression in the top level environment.
+func evalProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	form := datumToForm(arguments.(*Pair).ElementAt(0).Eval())
+	if form != Null && !form.isSymbol() {
+		form.setParent(arguments.Parent())
+	}
+	environment := running.environment
+	for environment.parent != nil {
+		environment = environment.parent
+	}
+	return environment.call(func() Object {
+		form = expand(form)
+		analyze(form, running.environment)
+		return form.Eval()
+	})
+}
+
+func macroexpandProc(arguments Object) Object {
+	return macroexpandByFunc(arguments, false)
+}
+
+func macroexpand1Proc(arguments Object) Object {
+	return macroexpandByFunc(arguments, true)
+}
+
+// Expand the given datum while it is a macro use, or only once.
+// Forms in the expansion are not expanded.
+func macroexpandByFunc(arguments Object, once bool) Object {
+	assertListEqual(arguments, 1)
+
+	form := datumToForm(arguments.(*Pair).ElementAt(0).Eval())
+	if form != Null && !form.isSymbol() {
+		form.setParent(arguments.Parent())
+	}
+	for expanded := true; expanded; {
+		form, expanded = expandMacroUse(form)
+		if once {
+			break
+		}
+	}
+	return formToDatum(form)
+}
+
+func stringToNumberProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	assertObjectType(object, "string")
+	if number := parseNumber(object.(*String).text); number != nil {
+		return number
+	}
+	return NewBoolean(false)
+}
+
+func numberToStringProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(objects, "number")
+	if len(objects) == 1 {
+		return NewString(objects[0].String())
+	}
+
+	radix := objects[1].(*Number)
+	if radix.kind != fixnumKind || !strings.Contains(" 2 8 10 16 ", fmt.Sprintf(" %d ", radix.value)) {
+		typeError(radix, "radix must be 2, 8, 10 or 16, but got %s", radix)
+	}
+	return NewString(objects[0].(*Number).text(radix.value))
+}
+
+func areIdentical(a Object, b Object) bool {
+	if typeName(a) != typeName(b) {
+		return false
+	}
+
+	switch a.(type) {
+	case *Number:
+		return a.(*Number).isEqv(b.(*Number))
+	case *Boolean:
+		return a.(*Boolean).value == b.(*Boolean).value
+	case *Char:
+		return a.(*Char).value == b.(*Char).value
+	default:
+		return a == b
+	}
+}
+
+func areEqual(a Object, b Object) bool {
+	if a == nil {
+		return true
+	}
 	if typeName(a) != typeName(b) {
 		return false
 	} else if areIdentical(a, b) {
@@ -421,6 +1835,20 @@ func areEqual(a Object, b Object) bool {
 	switch a.(type) {
 	case *Pair:
 		return areEqual(a.(*Pair).Car, b.(*Pair).Car) && areEqual(a.(*Pair).Cdr, b.(*Pair).Cdr)
+	case *String:
+		return a.(*String).text == b.(*String).text
+	case *Vector:
+		if len(a.(*Vector).elements) != len(b.(*Vector).elements) {
+			return false
+		}
+		for i, element := range a.(*Vector).elements {
+			if !areEqual(element, b.(*Vector).elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Bytevector:
+		return bytes.Equal(a.(*Bytevector).bytes, b.(*Bytevector).bytes)
 	default:
 		return false
 	}
@@ -457,6 +1885,99 @@ func isEqualProc(arguments Object) Object {
 	return NewBoolean(areEqual(objects[0], objects[1]))
 }
 
+func valuesProc(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+	return NewValues(evaledObjects(arguments.(*Pair).Elements())...)
+}
+
+// Calls producer without arguments, and then calls consumer with its values.
+func callWithValuesProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	procedures := evaledObjects(arguments.(*Pair).Elements())
+	return deferProcedure(procedures[1], valuesToObjects(applyProcedure(procedures[0]))...)
+}
+
+func callCCProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	procedure := arguments.(*Pair).ElementAt(0).Eval()
+	continuation := NewContinuation()
+	return continuation.catch(func() Object {
+		return applyProcedure(procedure, continuation)
+	})
+}
+
+// after thunk is deferred, so that it is called even when
+// a continuation or an error escapes from thunk.
+func dynamicWindProc(arguments Object) Object {
+	assertListEqual(arguments, 3)
+
+	thunks := evaledObjects(arguments.(*Pair).Elements())
+	applyProcedure(thunks[0])
+	defer applyProcedure(thunks[2])
+	return applyProcedure(thunks[1])
+}
+
+func errorProc(arguments Object) Object {
+	assertListMinimum(arguments, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	err := &RaiseError{newErrorBase("", nil, "%s", objects[0].(*String).text)}
+	err.irritants = objects[1:]
+	return raise(err, false)
+}
+
+func raiseProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+	return raise(arguments.(*Pair).ElementAt(0).Eval(), false)
+}
+
+func raiseContinuableProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+	return raise(arguments.(*Pair).ElementAt(0).Eval(), true)
+}
+
+func withExceptionHandlerProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	if !objects[0].isProcedure() {
+		typeError(objects[0], "procedure required, but got %s", objects[0])
+	}
+	return withExceptionHandler(objects[0], func() Object {
+		return applyProcedure(objects[1])
+	})
+}
+
+func isErrorObjectProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(Error)
+		return ok
+	})
+}
+
+func errorObjectMessageProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	if _, ok := object.(Error); !ok {
+		typeError(object, "error object required, but got %s", object)
+	}
+	return NewString(object.(Error).Message())
+}
+
+func errorObjectIrritantsProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	if _, ok := object.(Error); !ok {
+		typeError(object, "error object required, but got %s", object)
+	}
+	return NewList(nil, object.(Error).Irritants()...)
+}
+
 func loadProc(arguments Object) Object {
 	assertListEqual(arguments, 1)
 
@@ -470,8 +1991,14 @@ func loadProc(arguments Object) Object {
 	}
 
 	parser := NewParser(string(buffer))
+	parser.SetFilename(object.(*String).text)
 	for parser.Peek() != EOF {
-		expression := parser.Parse(arguments.Parent())
+		expression := parser.ParseForm()
+		if expression != nil && expression != Null && !expression.isSymbol() {
+			expression.setParent(arguments.Parent())
+		}
+		expression = expand(expression)
+		analyze(expression, running.environment)
 		if expression != nil {
 			expression.Eval()
 		}
@@ -480,18 +2007,389 @@ func loadProc(arguments Object) Object {
 	return NewBoolean(true)
 }
 
+// Returns the text which is printed by display, where strings and
+// characters are printed as they are, without quotes.
+func displayString(object Object) string {
+	switch object.(type) {
+	case *String:
+		return object.(*String).text
+	case *Char:
+		return string(object.(*Char).value)
+	case *Pair:
+		pair := object.(*Pair)
+		if pair.isNull() {
+			return "()"
+		} else if abbreviation, ok := pair.abbreviation(); ok {
+			return abbreviation + displayString(pair.Cdr.(*Pair).Car)
+		} else if !pair.isList() {
+			return fmt.Sprintf("(%s . %s)", displayString(pair.Car), displayString(pair.Cdr))
+		}
+		texts := []string{}
+		for _, element := range pair.Elements() {
+			texts = append(texts, displayString(element))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(texts, " "))
+	case *Vector:
+		texts := []string{}
+		for _, element := range object.(*Vector).elements {
+			texts = append(texts, displayString(element))
+		}
+		return fmt.Sprintf("#(%s)", strings.Join(texts, " "))
+	default:
+		return object.String()
+	}
+}
+
+// Returns the optional port at the index of objects, or the current output port.
+func outputPortArgument(objects []Object, index int) *Port {
+	if len(objects) <= index {
+		return currentOutputPort
+	} else if port, ok := objects[index].(*Port); !ok || !port.isOutput() {
+		typeError(objects[index], "output port required, but got %s", objects[index])
+	}
+	return objects[index].(*Port)
+}
+
+// Returns the optional port at the index of objects, or the current input port.
+func inputPortArgument(objects []Object, index int) *Port {
+	if len(objects) <= index {
+		return currentInputPort
+	} else if port, ok := objects[index].(*Port); !ok || !port.isInput() {
+		typeError(objects[index], "input port required, but got %s", objects[index])
+	}
+	return objects[index].(*Port)
+}
+
 func writeProc(arguments Object) Object {
-	assertListEqual(arguments, 1) // TODO: accept output port
+	assertListRange(arguments, 1, 2)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	fmt.Printf("%s\n", object)
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	outputPortArgument(objects, 1).write(objects[0].String())
+	return undef
+}
+
+func displayProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	outputPortArgument(objects, 1).write(displayString(objects[0]))
+	return undef
+}
+
+func newlineProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	outputPortArgument(objects, 0).write("\n")
 	return undef
 }
 
+// print displays the object followed by a newline.
 func printProc(arguments Object) Object {
-	assertListEqual(arguments, 1) // TODO: accept output port
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	outputPortArgument(objects, 1).write(displayString(objects[0]) + "\n")
+	return undef
+}
+
+// (write-string string [port [start [end]]])
+func writeStringProc(arguments Object) Object {
+	assertListRange(arguments, 1, 4)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	port := outputPortArgument(objects, 1)
+	runes := []rune(objects[0].(*String).text)
+	start, end := 0, len(runes)
+	if len(objects) > 2 {
+		start, end = rangeArguments(objects[2:], len(runes))
+	}
+	port.write(string(runes[start:end]))
+	return undef
+}
+
+func writeCharProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "char")
+	outputPortArgument(objects, 1).write(string(objects[0].(*Char).value))
+	return undef
+}
+
+func readCharProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	if char, ok := inputPortArgument(objects, 0).readRune(); ok {
+		return NewChar(char)
+	}
+	return eofObject
+}
+
+func peekCharProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	if char, ok := inputPortArgument(objects, 0).peekRune(); ok {
+		return NewChar(char)
+	}
+	return eofObject
+}
+
+func readLineProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	if line, ok := inputPortArgument(objects, 0).readLine(); ok {
+		return NewString(line)
+	}
+	return eofObject
+}
+
+// (read-string k [port])
+func readStringProc(arguments Object) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	count := indexArgument(objects[0], math.MaxInt32)
+	if text, ok := inputPortArgument(objects, 1).readString(count); ok {
+		return NewString(text)
+	}
+	return eofObject
+}
+
+func readProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	return inputPortArgument(objects, 0).read()
+}
+
+func isReadErrorProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*ReadError)
+		return ok
+	})
+}
+
+func isCharReadyProc(arguments Object) Object {
+	assertListRange(arguments, 0, 1)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	return NewBoolean(inputPortArgument(objects, 0).isReady())
+}
+
+func currentInputPortProc(arguments Object) Object {
+	assertListEqual(arguments, 0)
+	return currentInputPort
+}
+
+func currentOutputPortProc(arguments Object) Object {
+	assertListEqual(arguments, 0)
+	return currentOutputPort
+}
+
+func currentErrorPortProc(arguments Object) Object {
+	assertListEqual(arguments, 0)
+	return currentErrorPort
+}
+
+func isPortProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*Port)
+		return ok
+	})
+}
+
+func isInputPortProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		port, ok := object.(*Port)
+		return ok && port.isInput()
+	})
+}
+
+func isOutputPortProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		port, ok := object.(*Port)
+		return ok && port.isOutput()
+	})
+}
+
+func eofObjectProc(arguments Object) Object {
+	assertListEqual(arguments, 0)
+	return eofObject
+}
+
+func isEOFObjectProc(arguments Object) Object {
+	return booleanByFunc(arguments, func(object Object) bool {
+		return object == eofObject
+	})
+}
+
+func openInputFileProc(arguments Object) Object {
+	return stringByFunc(arguments, func(filename string) Object {
+		return openInputFile(filename)
+	})
+}
+
+func openOutputFileProc(arguments Object) Object {
+	return stringByFunc(arguments, func(filename string) Object {
+		return openOutputFile(filename)
+	})
+}
+
+// Apply the procedure to the port of the file, and close it after the procedure returns.
+func callWithPort(arguments Object, openFunc func(string) *Port) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	port := openFunc(objects[0].(*String).text)
+	defer port.close()
+	return applyProcedure(objects[1], port)
+}
+
+func callWithInputFileProc(arguments Object) Object {
+	return callWithPort(arguments, openInputFile)
+}
+
+func callWithOutputFileProc(arguments Object) Object {
+	return callWithPort(arguments, openOutputFile)
+}
+
+// Call the thunk while the current port is the port of the file.
+// The current port is restored and the file is closed when the thunk returns.
+func withPort(arguments Object, current **Port, openFunc func(string) *Port) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectType(objects[0], "string")
+	port := openFunc(objects[0].(*String).text)
+	defer port.close()
+	return withCurrentPort(current, port, objects[1])
+}
+
+// Call the thunk while the current port is the port, and restore it after the thunk returns.
+func withCurrentPort(current **Port, port *Port, thunk Object) Object {
+	outer := *current
+	*current = port
+	defer func() { *current = outer }()
+	return applyProcedure(thunk)
+}
+
+func withOutputToFileProc(arguments Object) Object {
+	return withPort(arguments, &currentOutputPort, openOutputFile)
+}
+
+func withInputFromFileProc(arguments Object) Object {
+	return withPort(arguments, &currentInputPort, openInputFile)
+}
+
+func openInputStringProc(arguments Object) Object {
+	return stringByFunc(arguments, func(text string) Object {
+		return openInputString(text)
+	})
+}
+
+func openOutputStringProc(arguments Object) Object {
+	assertListEqual(arguments, 0)
+	return openOutputString()
+}
+
+func getOutputStringProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0).Eval()
+	port, ok := object.(*Port)
+	if !ok {
+		typeError(object, "string output port required, but got %s", object)
+	}
+	text, ok := port.outputString()
+	if !ok {
+		typeError(object, "string output port required, but got %s", object)
+	}
+	return NewString(text)
+}
+
+// Returns the string which the thunk writes to the current output port.
+func withOutputToStringProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	port := openOutputString()
+	withCurrentPort(&currentOutputPort, port, arguments.(*Pair).ElementAt(0).Eval())
+	text, _ := port.outputString()
+	return NewString(text)
+}
+
+// Returns the string which the procedure writes to the port given as its argument.
+func callWithOutputStringProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
+
+	port := openOutputString()
+	applyProcedure(arguments.(*Pair).ElementAt(0).Eval(), port)
+	text, _ := port.outputString()
+	return NewString(text)
+}
+
+func closePortProc(arguments Object) Object {
+	assertListEqual(arguments, 1)
 
 	object := arguments.(*Pair).ElementAt(0).Eval()
-	fmt.Printf("%s\n", object)
+	assertObjectType(object, "port")
+	object.(*Port).close()
+	return undef
+}
+
+func fileExistsProc(arguments Object) Object {
+	return stringByFunc(arguments, func(filename string) Object {
+		_, err := os.Stat(filename)
+		return NewBoolean(err == nil)
+	})
+}
+
+func deleteFileProc(arguments Object) Object {
+	return stringByFunc(arguments, func(filename string) Object {
+		if err := os.Remove(filename); err != nil {
+			runtimeError("cannot delete file %q: %s", filename, err)
+		}
+		return undef
+	})
+}
+
+func renameFileProc(arguments Object) Object {
+	assertListEqual(arguments, 2)
+
+	objects := evaledObjects(arguments.(*Pair).Elements())
+	assertObjectsType(objects, "string")
+	oldName, newName := objects[0].(*String).text, objects[1].(*String).text
+	if err := os.Rename(oldName, newName); err != nil {
+		runtimeError("cannot rename file %q to %q: %s", oldName, newName, err)
+	}
 	return undef
 }
+
+func makeDirectoryProc(arguments Object) Object {
+	return stringByFunc(arguments, func(directory string) Object {
+		if err := os.Mkdir(directory, 0777); err != nil {
+			runtimeError("cannot make directory %q: %s", directory, err)
+		}
+		return undef
+	})
+}
+
+// Returns names of entries in the directory, which are sorted by name.
+func directoryListProc(arguments Object) Object {
+	return stringByFunc(arguments, func(directory string) Object {
+		entries, err := ioutil.ReadDir(directory)
+		if err != nil {
+			runtimeError("cannot read directory %q: %s", directory, err)
+		}
+		names := []Object{}
+		for _, entry := range entries {
+			names = append(names, NewString(entry.Name()))
+		}
+		return NewList(nil, names...)
+	})
+}
@@ -0,0 +1,47 @@
+// Bytevector is a type for scheme bytevector object, which is
+// expressed like #u8(1 2 255).
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bytevector is a struction for scheme bytevector object.
+type Bytevector struct {
+	ObjectBase
+	bytes []byte
+}
+
+// NewBytevector is a function for definition a new Bytevector object.
+func NewBytevector(bytes []byte, options ...Object) *Bytevector {
+	bytevector := &Bytevector{bytes: bytes}
+	if len(options) > 0 {
+		bytevector.parent = options[0]
+	}
+	return bytevector
+}
+
+// Eval is bytevector's eval IF.
+// Bytevector literal is self-evaluating.
+func (b *Bytevector) Eval() Object {
+	return b
+}
+
+func (b *Bytevector) String() string {
+	texts := []string{}
+	for _, value := range b.bytes {
+		texts = append(texts, fmt.Sprintf("%d", value))
+	}
+	return fmt.Sprintf("#u8(%s)", strings.Join(texts, " "))
+}
+
+// Returns the byte value of an exact integer from 0 to 255.
+func byteValue(object Object) byte {
+	assertObjectType(object, "number")
+	if number := object.(*Number); number.kind != fixnumKind || number.value < 0 || number.value > 255 {
+		typeError(object, "byte required, but got %s", object)
+	}
+	return byte(object.(*Number).value)
+}
@@ -0,0 +1,94 @@
+// Char is a type for scheme character object, which is
+// expressed like #\a, #\space or #\x41.
+
+package scheme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of characters which can be written as #\name.
+var charNames = map[string]rune{
+	"alarm":     '\a',
+	"backspace": '\b',
+	"delete":    0x7f,
+	"escape":    0x1b,
+	"newline":   '\n',
+	"null":      0,
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+}
+
+// Char is a struction for scheme character object.
+type Char struct {
+	ObjectBase
+	value rune
+}
+
+// NewChar is a function for definition a new Char object.
+// A string argument is parsed as character literal.
+func NewChar(object interface{}, options ...Object) *Char {
+	var char *Char
+	switch object.(type) {
+	case rune:
+		char = &Char{value: object.(rune)}
+	case string:
+		value, ok := parseChar(object.(string))
+		if !ok {
+			runtimeError("invalid character name: %s", object.(string))
+		}
+		char = &Char{value: value}
+	default:
+		runtimeError("Unexpected argument type for NewChar()")
+	}
+	if len(options) > 0 {
+		char.parent = options[0]
+	}
+	return char
+}
+
+// Parse character literal, such as #\a, #\space or #\x41.
+func parseChar(text string) (rune, bool) {
+	if !strings.HasPrefix(text, "#\\") || len(text) == 2 {
+		return 0, false
+	}
+	name := text[2:]
+	if utf8.RuneCountInString(name) == 1 {
+		value, _ := utf8.DecodeRuneInString(name)
+		return value, true
+	} else if value, ok := charNames[name]; ok {
+		return value, true
+	} else if name[0] == 'x' || name[0] == 'X' {
+		value, err := strconv.ParseUint(name[1:], 16, 32)
+		if err == nil && utf8.ValidRune(rune(value)) {
+			return rune(value), true
+		}
+	}
+	return 0, false
+}
+
+// Eval is char's eval IF.
+func (c *Char) Eval() Object {
+	return c
+}
+
+func (c *Char) String() string {
+	for name, value := range charNames {
+		if c.value == value {
+			return "#\\" + name
+		}
+	}
+	if !unicode.IsGraphic(c.value) {
+		return fmt.Sprintf("#\\x%x", c.value)
+	}
+	return "#\\" + string(c.value)
+}
+
+func (c *Char) isChar() bool {
+	return true
+}
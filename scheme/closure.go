@@ -1,30 +1,29 @@
 // Closure is an object returned by lambda.
-// It has references for closures scoped when it was generated.
+// It has a reference for the environment where it was generated,
+// and each invocation creates a new frame in the environment.
 
 package scheme
 
 type Closure struct {
 	ObjectBase
-	localBinding Binding
-	function     func(Object) Object
+	function    func(Object) Object
+	environment *Environment
+	lambda      *lambda // compiled body, or nil when it is evaluated by the evaluator
 }
 
-func NewClosure(parent Object) *Closure {
-	return &Closure{ObjectBase: ObjectBase{parent: parent}, localBinding: make(Binding)}
-}
-
-// Cover the given object with a new closure.
-// Insert this into tree structure between given object and its parent.
-func WrapClosure(wrappedObject Object) *Closure {
-	closure := NewClosure(wrappedObject.Parent())
-	wrappedObject.setParent(closure)
-	return closure
+func NewClosure(parent Object, environment *Environment) *Closure {
+	return &Closure{ObjectBase: ObjectBase{parent: parent}, environment: environment}
 }
 
 func (c *Closure) String() string {
 	return "#<closure #f>"
 }
 
+// Eval is Closure's eval IF.
+func (c *Closure) Eval() Object {
+	return c
+}
+
 func (c *Closure) Invoke(argument Object) Object {
 	return c.function(argument)
 }
@@ -36,31 +35,3 @@ func (c *Closure) isClosure() bool {
 func (c *Closure) isProcedure() bool {
 	return true
 }
-
-// This method is for define syntax form.
-// Define a local variable in the most inner closure.
-func (c *Closure) define(identifier string, object Object) {
-	c.localBinding[identifier] = object
-}
-
-// This method is for set! syntax form.
-// Update most inner scoped closure's binding, otherwise raise error.
-func (c *Closure) set(identifier string, object Object) {
-	if c.localBinding[identifier] == nil {
-		if c.parent == nil {
-			runtimeError("symbol not defined")
-		} else {
-			c.parent.set(identifier, object)
-		}
-	} else {
-		c.localBinding[identifier] = object
-	}
-}
-
-func (c *Closure) binding() Binding {
-	return c.localBinding
-}
-
-func (c *Closure) scopedBinding() Binding {
-	return c.localBinding
-}
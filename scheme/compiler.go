@@ -0,0 +1,605 @@
+// Compiler compiles the analyzed AST to the code of the virtual machine.
+// Syntax forms for control flow and bindings, such as if, lambda and let,
+// are compiled to instructions. Their frames are laid out in the same way
+// as the evaluator, so that compiled code and the evaluator share
+// environments. Other forms, such as guard and define-record-type, and
+// malformed forms are evaluated by the evaluator from the compiled code.
+
+package scheme
+
+import "gosc/vm"
+
+// Compiler is a struction for compilation of a procedure body.
+type compiler struct {
+	code *vm.Code
+	// The frame where keywords are looked up.
+	environment *Environment
+}
+
+// Lambda is a struction for compiled lambda, which is a constant of the code.
+type lambda struct {
+	code       *vm.Code
+	form       Object // the scope of frames of invocations
+	index      int    // the position of the frame in ones created by the form
+	parameters []string
+	rest       *Variable
+	laidOut    bool // whether parameters are the first values of the frame
+}
+
+// Layout of the frame created by binding form, which is a constant of the code.
+type frameLayout struct {
+	form        Object
+	index       int
+	identifiers []string
+	laidOut     bool // whether identifiers are the first values of the frame
+}
+
+func newFrameLayout(form Object, index int, identifiers []string) *frameLayout {
+	return &frameLayout{form: form, index: index, identifiers: identifiers, laidOut: isLaidOut(form, index, identifiers)}
+}
+
+// Compile the expression, which is evaluated in the environment.
+func compile(object Object, environment *Environment) *vm.Code {
+	c := &compiler{code: vm.NewCode(), environment: environment}
+	c.compile(object, false)
+	c.code.Emit(vm.Return)
+	return c.code
+}
+
+// Compile the object, whose value is pushed. When the object is in tail
+// position, the code returns its value instead.
+func (c *compiler) compile(object Object, tail bool) {
+	switch object.(type) {
+	case *Variable:
+		c.code.Emit(vm.Load, c.code.AddConstant(object))
+		c.compileReturn(tail)
+	case *Application:
+		c.compileApplication(object.(*Application), tail)
+	default:
+		c.compileConstant(object, tail)
+	}
+}
+
+func (c *compiler) compileConstant(object Object, tail bool) {
+	c.code.Emit(vm.Constant, c.code.AddConstant(object))
+	c.compileReturn(tail)
+}
+
+func (c *compiler) compileReturn(tail bool) {
+	if tail {
+		c.code.Emit(vm.Return)
+	}
+}
+
+// The object is evaluated by the evaluator.
+func (c *compiler) compileEval(object Object, tail bool) {
+	if tail {
+		c.code.Emit(vm.TailEval, c.code.AddConstant(object))
+	} else {
+		c.code.Emit(vm.Eval, c.code.AddConstant(object))
+	}
+}
+
+// Compile each object in order, and the last one is in tail position
+// when the body is.
+func (c *compiler) compileBody(body []Object, tail bool) {
+	if len(body) == 0 {
+		c.compileConstant(undef, tail)
+		return
+	}
+	for _, object := range body[:len(body)-1] {
+		c.compile(object, false)
+		c.code.Emit(vm.Pop)
+	}
+	c.compile(body[len(body)-1], tail)
+}
+
+func (c *compiler) compileApplication(application *Application, tail bool) {
+	elements, ok := formElements(application)
+	if !ok {
+		c.compileEval(application, tail)
+		return
+	}
+	variable, ok := application.procedure.(*Variable)
+	if !ok {
+		c.compileCall(application, elements, tail)
+		return
+	}
+
+	keyword := c.keyword(variable)
+	compiled := true
+	switch keyword {
+	case builtinSyntaxes["quote"]:
+		compiled = c.compileQuote(application, elements, tail)
+	case builtinSyntaxes["if"]:
+		compiled = c.compileIf(elements, tail)
+	case builtinSyntaxes["define"]:
+		compiled = c.compileDefine(elements, tail)
+	case builtinSyntaxes["set!"]:
+		compiled = c.compileSet(elements, tail)
+	case builtinSyntaxes["lambda"]:
+		compiled = c.compileLambda(application, elements, tail)
+	case builtinSyntaxes["begin"]:
+		c.compileBody(elements[1:], tail)
+	case builtinSyntaxes["and"]:
+		c.compileAndOr(elements[1:], vm.JumpIfFalse, tail)
+	case builtinSyntaxes["or"]:
+		c.compileAndOr(elements[1:], vm.JumpIfTrue, tail)
+	case builtinSyntaxes["cond"]:
+		compiled = c.compileCond(elements, tail)
+	case builtinSyntaxes["let"]:
+		compiled = c.compileLet(application, elements, tail)
+	case builtinSyntaxes["let*"]:
+		compiled = c.compileLetStar(application, elements, tail)
+	case builtinSyntaxes["letrec"], builtinSyntaxes["letrec*"]:
+		compiled = c.compileLetrec(application, elements, keyword == builtinSyntaxes["letrec*"], tail)
+	case builtinSyntaxes["do"]:
+		compiled = c.compileDo(application, elements, tail)
+	default:
+		switch keyword.(type) {
+		case *Syntax, *Macro:
+			compiled = false
+		default:
+			c.compileCall(application, elements, tail)
+		}
+	}
+	if !compiled {
+		c.compileEval(application, tail)
+	}
+}
+
+// Returns the bound syntax or macro, or nil for local variables.
+func (c *compiler) keyword(variable *Variable) Object {
+	if variable.address != nil {
+		return nil
+	}
+	return c.environment.boundedObject(variable)
+}
+
+// (procedure argument ...)
+// The application is recorded as the call site before the procedure is
+// evaluated, as the evaluator does. When the procedure may be a syntax,
+// such as a macro defined after the application is compiled, the
+// application is evaluated by the evaluator instead.
+func (c *compiler) compileCall(application *Application, elements []Object, tail bool) {
+	site := c.code.AddConstant(application)
+	if tail {
+		c.code.Emit(vm.TailSite, site)
+	} else {
+		c.code.Emit(vm.Site, site)
+	}
+
+	c.compile(elements[0], false)
+	check := -1
+	if variable, ok := elements[0].(*Variable); ok {
+		if object := c.keyword(variable); object == nil || !object.isProcedure() {
+			if tail {
+				c.code.Emit(vm.TailCheck, site)
+			} else {
+				check = c.code.Emit(vm.Check, site)
+			}
+		}
+	}
+
+	for _, argument := range elements[1:] {
+		c.compile(argument, false)
+	}
+	if tail {
+		c.code.Emit(vm.TailCall, len(elements)-1)
+	} else {
+		c.code.Emit(vm.Call, len(elements)-1)
+	}
+	if check >= 0 {
+		c.code.Patch(check)
+	}
+}
+
+// (quote datum)
+func (c *compiler) compileQuote(application *Application, elements []Object, tail bool) bool {
+	if len(elements) != 2 {
+		return false
+	}
+	c.compileConstant(quotedDatum(application), tail)
+	return true
+}
+
+// (if test consequent [alternate])
+func (c *compiler) compileIf(elements []Object, tail bool) bool {
+	if len(elements) != 3 && len(elements) != 4 {
+		return false
+	}
+	c.compile(elements[1], false)
+	alternate := c.code.Emit(vm.JumpIfFalse)
+	c.compile(elements[2], tail)
+	end := -1
+	if !tail {
+		end = c.code.Emit(vm.Jump)
+	}
+	c.code.Patch(alternate)
+	if len(elements) == 4 {
+		c.compile(elements[3], tail)
+	} else {
+		c.compileConstant(undef, tail)
+	}
+	if end >= 0 {
+		c.code.Patch(end)
+	}
+	return true
+}
+
+// (define variable expression)
+func (c *compiler) compileDefine(elements []Object, tail bool) bool {
+	if len(elements) != 3 || !elements[1].isVariable() {
+		return false
+	}
+	c.compile(elements[2], false)
+	c.code.Emit(vm.Define, c.code.AddConstant(elements[1]))
+	c.compileConstant(NewSymbol(elements[1].(*Variable).identifier), tail)
+	return true
+}
+
+// (set! variable expression)
+func (c *compiler) compileSet(elements []Object, tail bool) bool {
+	if len(elements) != 3 || !elements[1].isVariable() {
+		return false
+	}
+	c.compile(elements[2], false)
+	c.code.Emit(vm.Store, c.code.AddConstant(elements[1]))
+	c.compileReturn(tail)
+	return true
+}
+
+// (lambda parameters body ...)
+func (c *compiler) compileLambda(application *Application, elements []Object, tail bool) bool {
+	if len(elements) < 2 {
+		return false
+	}
+	procedure, ok := c.compileProcedure(application.arguments.Parent(), 0, elements[1], elements[2:])
+	if !ok {
+		return false
+	}
+	c.code.Emit(vm.Closure, c.code.AddConstant(procedure))
+	c.compileReturn(tail)
+	return true
+}
+
+// Compile the body of procedure, whose invocation creates the frame of the
+// form at the index. Returns false when parameters are malformed.
+func (c *compiler) compileProcedure(form Object, index int, parameters Object, body []Object) (*lambda, bool) {
+	procedure := &lambda{form: form, index: index}
+	tail := parameters
+	if application, ok := parameters.(*Application); ok {
+		if !application.procedure.isVariable() {
+			return nil, false
+		}
+		procedure.parameters = append(procedure.parameters, application.procedure.(*Variable).identifier)
+		tail = application.arguments
+	}
+	for ; tail.isPair(); tail = tail.(*Pair).Cdr {
+		if !tail.(*Pair).Car.isVariable() {
+			return nil, false
+		}
+		procedure.parameters = append(procedure.parameters, tail.(*Pair).Car.(*Variable).identifier)
+	}
+	if tail.isVariable() {
+		procedure.rest = tail.(*Variable)
+	} else if !tail.isNull() {
+		return nil, false
+	}
+
+	procedure.laidOut = isLaidOut(form, index, procedure.parameters)
+
+	compiler := &compiler{code: vm.NewCode(), environment: c.environment}
+	compiler.compileBody(body, true)
+	procedure.code = compiler.code
+	return procedure, true
+}
+
+// Returns true when the frame of the form at the index is laid out by
+// analysis, and its first values are the identifiers in order.
+func isLaidOut(form Object, index int, identifiers []string) bool {
+	application, ok := form.(*Application)
+	if !ok || index >= len(application.frames) || len(application.frames[index]) < len(identifiers) {
+		return false
+	}
+	for i, identifier := range identifiers {
+		if application.frames[index][i] != identifier {
+			return false
+		}
+	}
+	return true
+}
+
+// (and test ...) and (or test ...)
+// Each test except the last one jumps to the end with its value, when the
+// value is false for and, or it is not false for or.
+func (c *compiler) compileAndOr(tests []Object, jump vm.Opcode, tail bool) {
+	if len(tests) == 0 {
+		c.compileConstant(NewBoolean(jump == vm.JumpIfFalse), tail)
+		return
+	}
+	ends := []int{}
+	for _, test := range tests[:len(tests)-1] {
+		c.compile(test, false)
+		c.code.Emit(vm.Dup)
+		ends = append(ends, c.code.Emit(jump))
+		c.code.Emit(vm.Pop)
+	}
+	c.compile(tests[len(tests)-1], tail)
+	for _, end := range ends {
+		c.code.Patch(end)
+	}
+	if len(ends) > 0 {
+		c.compileReturn(tail)
+	}
+}
+
+// (cond (test expression ...) ... [(else expression ...)])
+// The clause without expressions returns the value of its test.
+func (c *compiler) compileCond(elements []Object, tail bool) bool {
+	clauses := elements[1:]
+	if len(clauses) == 0 {
+		return false
+	}
+	for index, clause := range clauses {
+		application, ok := clause.(*Application)
+		if !ok || !application.arguments.isList() {
+			return false
+		} else if isKeyword(application.procedure, "else") && index != len(clauses)-1 {
+			return false
+		}
+	}
+
+	ends := []int{}
+	for _, clause := range clauses {
+		application := clause.(*Application)
+		body := application.arguments.(*Pair).Elements()
+		if isKeyword(application.procedure, "else") {
+			c.compileBody(body, tail)
+			break
+		}
+
+		c.compile(application.procedure, false)
+		if len(body) == 0 {
+			c.code.Emit(vm.Dup)
+		}
+		next := c.code.Emit(vm.JumpIfFalse)
+		if len(body) == 0 {
+			c.compileReturn(tail)
+		} else {
+			c.compileBody(body, tail)
+		}
+		if !tail {
+			ends = append(ends, c.code.Emit(vm.Jump))
+		}
+		c.code.Patch(next)
+		if len(body) == 0 {
+			c.code.Emit(vm.Pop)
+		}
+	}
+	if !isKeyword(clauses[len(clauses)-1].(*Application).procedure, "else") {
+		c.compileConstant(undef, tail)
+	}
+	for _, end := range ends {
+		c.code.Patch(end)
+	}
+	return true
+}
+
+// Returns variables and inits of ((variable init) ...), or false when
+// bindings are malformed.
+func compiledBindings(bindings Object) ([]*Variable, []Object, bool) {
+	elements, ok := formElements(bindings)
+	if !ok {
+		return nil, nil, false
+	}
+	variables, inits := []*Variable{}, []Object{}
+	for _, binding := range elements {
+		bindingElements, ok := formElements(binding)
+		if !ok || len(bindingElements) != 2 || !bindingElements[0].isVariable() {
+			return nil, nil, false
+		}
+		variables = append(variables, bindingElements[0].(*Variable))
+		inits = append(inits, bindingElements[1])
+	}
+	return variables, inits, true
+}
+
+func identifiersOf(variables []*Variable) []string {
+	identifiers := []string{}
+	for _, variable := range variables {
+		identifiers = append(identifiers, variable.identifier)
+	}
+	return identifiers
+}
+
+// Leave frames of the binding form after its body, when it is not in tail position.
+func (c *compiler) compileLeave(count int, tail bool) {
+	if !tail && count > 0 {
+		c.code.Emit(vm.Leave, count)
+	}
+}
+
+// (let [name] ((variable init) ...) body ...)
+func (c *compiler) compileLet(application *Application, elements []Object, tail bool) bool {
+	if len(elements) < 2 {
+		return false
+	} else if elements[1].isVariable() {
+		return c.compileNamedLet(application, elements, tail)
+	}
+	variables, inits, ok := compiledBindings(elements[1])
+	if !ok {
+		return false
+	}
+
+	for _, init := range inits {
+		c.compile(init, false)
+	}
+	layout := newFrameLayout(application.arguments.Parent(), 0, identifiersOf(variables))
+	c.code.Emit(vm.Enter, c.code.AddConstant(layout), len(inits))
+	c.compileBody(elements[2:], tail)
+	c.compileLeave(1, tail)
+	return true
+}
+
+// (let name ((variable init) ...) body ...)
+// The procedure is bound to the name in its own frame, and it is called
+// with inits evaluated in the outer frame.
+func (c *compiler) compileNamedLet(application *Application, elements []Object, tail bool) bool {
+	if len(elements) < 3 {
+		return false
+	}
+	variables, inits, ok := compiledBindings(elements[2])
+	if !ok {
+		return false
+	}
+	parameters := make([]Object, len(variables))
+	for index, variable := range variables {
+		parameters[index] = variable
+	}
+	form := application.arguments.Parent()
+	procedure, ok := c.compileProcedure(form, 1, NewList(nil, parameters...), elements[3:])
+	if !ok {
+		return false
+	}
+
+	site := c.code.AddConstant(application)
+	if tail {
+		c.code.Emit(vm.TailSite, site)
+	} else {
+		c.code.Emit(vm.Site, site)
+	}
+	layout := newFrameLayout(form, 0, nil)
+	c.code.Emit(vm.Enter, c.code.AddConstant(layout), 0)
+	c.code.Emit(vm.Closure, c.code.AddConstant(procedure))
+	c.code.Emit(vm.Dup)
+	c.code.Emit(vm.Define, c.code.AddConstant(elements[1]))
+	c.code.Emit(vm.Leave, 1)
+	for _, init := range inits {
+		c.compile(init, false)
+	}
+	if tail {
+		c.code.Emit(vm.TailCall, len(inits))
+	} else {
+		c.code.Emit(vm.Call, len(inits))
+	}
+	return true
+}
+
+// (let* ((variable init) ...) body ...)
+// Each variable is bound in its own frame, and the body is in the last frame.
+func (c *compiler) compileLetStar(application *Application, elements []Object, tail bool) bool {
+	if len(elements) < 2 {
+		return false
+	}
+	variables, inits, ok := compiledBindings(elements[1])
+	if !ok {
+		return false
+	}
+
+	form := application.arguments.Parent()
+	for index, init := range inits {
+		c.compile(init, false)
+		layout := newFrameLayout(form, index, []string{variables[index].identifier})
+		c.code.Emit(vm.Enter, c.code.AddConstant(layout), 1)
+	}
+	c.code.Emit(vm.Enter, c.code.AddConstant(newFrameLayout(form, len(inits), nil)), 0)
+	c.compileBody(elements[2:], tail)
+	c.compileLeave(len(inits)+1, tail)
+	return true
+}
+
+// (letrec ((variable init) ...) body ...) and letrec*
+// Inits are evaluated in the frame of the variables. The variables of
+// letrec are bound after all inits are evaluated, and ones of letrec*
+// are bound just after each init.
+func (c *compiler) compileLetrec(application *Application, elements []Object, sequential bool, tail bool) bool {
+	if len(elements) < 2 {
+		return false
+	}
+	variables, inits, ok := compiledBindings(elements[1])
+	if !ok {
+		return false
+	}
+
+	c.code.Emit(vm.Enter, c.code.AddConstant(newFrameLayout(application.arguments.Parent(), 0, nil)), 0)
+	for index, init := range inits {
+		c.compile(init, false)
+		if sequential {
+			c.code.Emit(vm.Define, c.code.AddConstant(variables[index]))
+		}
+	}
+	if !sequential {
+		for index := len(variables) - 1; index >= 0; index-- {
+			c.code.Emit(vm.Define, c.code.AddConstant(variables[index]))
+		}
+	}
+	c.compileBody(elements[2:], tail)
+	c.compileLeave(1, tail)
+	return true
+}
+
+// (do ((variable init [step]) ...) (test expression ...) body ...)
+// Each iteration binds the variables in a new frame, whose values are
+// evaluated in the frame of the previous iteration.
+func (c *compiler) compileDo(application *Application, elements []Object, tail bool) bool {
+	if len(elements) < 3 {
+		return false
+	}
+	iterators, ok := formElements(elements[1])
+	if !ok {
+		return false
+	}
+	variables, inits, steps := []*Variable{}, []Object{}, []Object{}
+	for _, iterator := range iterators {
+		iteratorElements, ok := formElements(iterator)
+		if !ok || len(iteratorElements) < 2 || len(iteratorElements) > 3 || !iteratorElements[0].isVariable() {
+			return false
+		}
+		variables = append(variables, iteratorElements[0].(*Variable))
+		inits = append(inits, iteratorElements[1])
+		steps = append(steps, iteratorElements[len(iteratorElements)-1])
+		if len(iteratorElements) == 2 {
+			steps[len(steps)-1] = iteratorElements[0]
+		}
+	}
+	testElements, ok := formElements(elements[2])
+	if !ok || len(testElements) == 0 {
+		return false
+	}
+
+	for _, init := range inits {
+		c.compile(init, false)
+	}
+	layout := c.code.AddConstant(newFrameLayout(application.arguments.Parent(), 0, identifiersOf(variables)))
+	c.code.Emit(vm.Enter, layout, len(inits))
+
+	loop := c.code.Next()
+	c.compile(testElements[0], false)
+	if len(testElements) == 1 {
+		c.code.Emit(vm.Dup)
+	}
+	exit := c.code.Emit(vm.JumpIfTrue)
+	if len(testElements) == 1 {
+		c.code.Emit(vm.Pop)
+	}
+	for _, object := range elements[3:] {
+		c.compile(object, false)
+		c.code.Emit(vm.Pop)
+	}
+	for _, step := range steps {
+		c.compile(step, false)
+	}
+	c.code.Emit(vm.Leave, 1)
+	c.code.Emit(vm.Enter, layout, len(steps))
+	c.code.Emit(vm.Jump, loop)
+
+	c.code.Patch(exit)
+	if len(testElements) == 1 {
+		c.compileReturn(tail)
+	} else {
+		c.compileBody(testElements[1:], tail)
+	}
+	c.compileLeave(1, tail)
+	return true
+}
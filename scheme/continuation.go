@@ -0,0 +1,76 @@
+// Continuation is a type for scheme continuation, which is captured by
+// call-with-current-continuation.
+// Since the evaluator runs on Go's stack, a continuation only supports
+// escaping to the point where it was captured, while call/cc is still
+// running. Calling it unwinds Go's stack by panic until call/cc recovers.
+
+package scheme
+
+// Continuation is a struction for escape procedure.
+type Continuation struct {
+	ObjectBase
+	active bool
+}
+
+// Panic value to unwind Go's stack to the call/cc of the continuation.
+type continuationEscape struct {
+	continuation *Continuation
+	value        Object
+}
+
+// NewContinuation is a function for definition a new Continuation.
+func NewContinuation() *Continuation {
+	return &Continuation{active: true}
+}
+
+// Eval is Continuation's eval IF.
+func (c *Continuation) Eval() Object {
+	return c
+}
+
+func (c *Continuation) String() string {
+	return "#<continuation>"
+}
+
+// Invoke is Continuation's function IF.
+func (c *Continuation) Invoke(arguments Object) Object {
+	assertListMinimum(arguments, 0)
+	objects := evaledObjects(arguments.(*Pair).Elements())
+
+	value := undef
+	switch len(objects) {
+	case 0:
+	case 1:
+		value = objects[0]
+	default:
+		arityError(arguments, "wrong number of arguments: requires 1, but got %d", len(objects))
+	}
+
+	if !c.active {
+		runtimeError("continuation called outside of its dynamic extent")
+	}
+	panic(&continuationEscape{continuation: c, value: value})
+}
+
+// Call function and returns its result. When the continuation is called
+// while function is running, returns the value passed to the continuation.
+// The current environment is restored when the continuation is called.
+func (c *Continuation) catch(function func() Object) (result Object) {
+	environment := running.environment
+	defer func() {
+		c.active = false
+		if err := recover(); err != nil {
+			escape, ok := err.(*continuationEscape)
+			if !ok || escape.continuation != c {
+				panic(err)
+			}
+			running.environment = environment
+			result = escape.value
+		}
+	}()
+	return function()
+}
+
+func (c *Continuation) isProcedure() bool {
+	return true
+}
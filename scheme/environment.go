@@ -0,0 +1,194 @@
+// Environment is a frame of variable bindings, which refers to the frame
+// of the outer scope. A frame is created for each invocation of procedure
+// and each evaluation of binding form such as let, so that variables of
+// recursive calls or closures created in a loop are distinct.
+// Values of a frame are laid out by analysis, so that an analyzed variable
+// is looked up by its lexical address. Other variables are looked up by
+// their identifiers from the current frame to the top level one.
+
+package scheme
+
+// Environment is a struction for a frame of bindings.
+type Environment struct {
+	identifiers []string // identifiers of values, which may be shared with other frames
+	values      []Object
+	cells       map[string]*cell // bindings of the top level frame
+	parent      *Environment
+	scope       Object // the form which created this frame, nil for the top level
+	outside     Object // the part of the scope which this frame does not enclose, such as transformers of let-syntax
+}
+
+// Cell holds the value of a top level variable, so that analyzed variables
+// refer to it directly. The value is nil while the variable is not defined.
+type cell struct {
+	value Object
+}
+
+// NewEnvironment is a function for definition a new frame in the parent.
+// The frame is laid out by analysis of the scope, where index is
+// the position of the frame in ones created by the scope, such as let*.
+func NewEnvironment(parent *Environment, scope Object, index int) *Environment {
+	frame := &Environment{parent: parent, scope: scope}
+	if application, ok := scope.(*Application); ok && index < len(application.frames) {
+		frame.identifiers = application.frames[index]
+		frame.values = make([]Object, len(frame.identifiers))
+	}
+	return frame
+}
+
+// NewTopLevelEnvironment is a function for definition the top level frame.
+func NewTopLevelEnvironment(binding Binding) *Environment {
+	frame := &Environment{cells: map[string]*cell{}}
+	for identifier, object := range binding {
+		frame.define(identifier, object)
+	}
+	return frame
+}
+
+// Call the function in this frame, and returns its result.
+// The outer frame is restored also when an error or a continuation escapes.
+func (e *Environment) call(function func() Object) Object {
+	environment := running.environment
+	running.environment = e
+	defer func() { running.environment = environment }()
+	return function()
+}
+
+// Evaluate the object in this frame.
+func (e *Environment) eval(object Object) Object {
+	return e.call(object.Eval)
+}
+
+// Evaluate the body in this frame, the last expression is returned as
+// TailCall which is evaluated in this frame.
+func (e *Environment) evalBody(body []Object) Object {
+	return e.call(func() Object { return evalBody(body) })
+}
+
+// This method is for define syntax form.
+// Define a variable in this frame.
+func (e *Environment) define(identifier string, object Object) {
+	if e.cells != nil {
+		e.cell(identifier).value = object
+	} else if index := e.indexOf(identifier); index >= 0 {
+		e.values[index] = object
+	} else {
+		// Identifiers are copied, because they may be shared with other frames.
+		e.identifiers = append(e.identifiers[:len(e.identifiers):len(e.identifiers)], identifier)
+		e.values = append(e.values, object)
+	}
+}
+
+// This method is for set! syntax form.
+// Update the binding in the frame where the variable is bound, otherwise raise error.
+func (e *Environment) set(variable *Variable, object Object) {
+	if variable.address != nil {
+		frame := e.addressed(variable)
+		if frame.values[variable.address.index] == nil {
+			runtimeError("symbol not defined")
+		}
+		frame.values[variable.address.index] = object
+		return
+	} else if variable.cell != nil {
+		if variable.cell.value == nil {
+			runtimeError("symbol not defined")
+		}
+		variable.cell.value = object
+		return
+	}
+
+	frame, target := e.lookup(variable)
+	if frame == nil {
+		runtimeError("symbol not defined")
+	}
+	frame.define(target.identifier, object)
+}
+
+// Returns the bound object, or nil when the variable is not bound.
+// The analyzed variable is found at its address or in its cell, without
+// searching identifiers.
+func (e *Environment) boundedObject(variable *Variable) Object {
+	if variable.address != nil {
+		return e.addressed(variable).values[variable.address.index]
+	} else if variable.cell != nil {
+		return variable.cell.value
+	}
+
+	if frame, target := e.lookup(variable); frame != nil {
+		return frame.get(target.identifier)
+	}
+	return nil
+}
+
+// Returns the frame where the analyzed variable is laid out.
+func (e *Environment) addressed(variable *Variable) *Environment {
+	frame := e
+	for depth := variable.address.depth; depth > 0; depth-- {
+		frame = frame.parent
+	}
+	return frame
+}
+
+// Returns the frame where the variable is bound, and the variable itself
+// or its original variable, which is bound.
+// Renamed variable which is not bound refers to the original variable
+// in the macro template, which is bound in the scope of macro definition.
+func (e *Environment) lookup(variable *Variable) (*Environment, *Variable) {
+	for target := variable; target != nil; target = target.original {
+		for frame := e; frame != nil; frame = frame.parent {
+			if frame.get(target.identifier) != nil && (target == variable || frame.encloses(target)) {
+				return frame, target
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Returns the object bound in this frame, or nil.
+func (e *Environment) get(identifier string) Object {
+	if e.cells != nil {
+		if cell, ok := e.cells[identifier]; ok {
+			return cell.value
+		}
+		return nil
+	} else if index := e.indexOf(identifier); index >= 0 {
+		return e.values[index]
+	}
+	return nil
+}
+
+func (e *Environment) indexOf(identifier string) int {
+	for index, bound := range e.identifiers {
+		if bound == identifier {
+			return index
+		}
+	}
+	return -1
+}
+
+// Returns the cell of the top level variable, which is created when
+// the variable is not defined yet.
+func (e *Environment) cell(identifier string) *cell {
+	if _, ok := e.cells[identifier]; !ok {
+		e.cells[identifier] = &cell{}
+	}
+	return e.cells[identifier]
+}
+
+// Returns true when the object is in the scope of this frame.
+func (e *Environment) encloses(object Object) bool {
+	if e.scope == nil {
+		return true
+	}
+	return isWithin(object, e.scope) && !isWithin(object, e.outside)
+}
+
+// Returns true when the object is the ancestor or in it.
+func isWithin(object Object, ancestor Object) bool {
+	for parent := object; parent != nil; parent = parent.Parent() {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
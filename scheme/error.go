@@ -0,0 +1,252 @@
+// Error types raised while evaluating scheme program.
+// Each error carries a message, the offending object and irritants,
+// so that Go code embedding the interpreter can distinguish failures
+// by errors.As() instead of matching error message.
+// An error is also a scheme object to be handled by scheme program.
+//
+// Errors are passed to the current exception handler installed by
+// with-exception-handler or guard. When there is no handler,
+// the error panics and it is recovered by Interpreter.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+	"text/scanner"
+)
+
+// Error is an interface implemented by all errors of the interpreter.
+type Error interface {
+	error
+	Object
+	Message() string
+	Object() Object
+	Irritants() []Object
+	StackTrace() []Frame
+}
+
+// ErrorBase is an abstruct class for errors of the interpreter.
+type ErrorBase struct {
+	ObjectBase
+	prefix     string
+	message    string
+	object     Object
+	irritants  []Object
+	stackTrace []Frame
+}
+
+// SyntaxError is raised for malformed syntax form.
+type SyntaxError struct {
+	ErrorBase
+}
+
+// UnboundVariableError is raised for reference to undefined variable.
+type UnboundVariableError struct {
+	ErrorBase
+}
+
+// ArityError is raised for wrong number of arguments.
+type ArityError struct {
+	ErrorBase
+}
+
+// TypeError is raised for an argument of unexpected type.
+type TypeError struct {
+	ErrorBase
+}
+
+// RaiseError is raised for an object raised by scheme program.
+type RaiseError struct {
+	ErrorBase
+}
+
+// RuntimeError is raised for other errors while evaluating.
+type RuntimeError struct {
+	ErrorBase
+}
+
+// ReadError is raised for malformed input of read procedure.
+// It is positioned at the datum in the input port.
+type ReadError struct {
+	ErrorBase
+}
+
+// Error returns error message with irritants.
+// When the error is raised from a source file, the message starts with
+// its position like "foo.scm:132:7: ".
+func (e *ErrorBase) Error() string {
+	texts := []string{e.prefix + e.message}
+	for _, irritant := range e.irritants {
+		texts = append(texts, irritant.String())
+	}
+	if e.position != nil && e.position.Filename != "" {
+		return fmt.Sprintf("%s: %s", e.position, strings.Join(texts, " "))
+	}
+	return strings.Join(texts, " ")
+}
+
+// Message returns error message without irritants.
+func (e *ErrorBase) Message() string {
+	return e.message
+}
+
+// Object returns the offending object, or nil when it is unknown.
+func (e *ErrorBase) Object() Object {
+	return e.object
+}
+
+// Irritants returns objects which is attached to the message.
+func (e *ErrorBase) Irritants() []Object {
+	return e.irritants
+}
+
+// StackTrace returns frames of applications when the error was raised.
+// The innermost frame comes first.
+func (e *ErrorBase) StackTrace() []Frame {
+	return e.stackTrace
+}
+
+// Eval is error's eval IF.
+// Each error type returns itself not to be converted to ErrorBase.
+func (e *SyntaxError) Eval() Object {
+	return e
+}
+
+func (e *UnboundVariableError) Eval() Object {
+	return e
+}
+
+func (e *ArityError) Eval() Object {
+	return e
+}
+
+func (e *TypeError) Eval() Object {
+	return e
+}
+
+func (e *RaiseError) Eval() Object {
+	return e
+}
+
+func (e *RuntimeError) Eval() Object {
+	return e
+}
+
+func (e *ReadError) Eval() Object {
+	return e
+}
+
+func (e *ErrorBase) String() string {
+	return fmt.Sprintf("#<error %s>", e.Error())
+}
+
+func syntaxError(form Object, format string, a ...interface{}) {
+	raise(&SyntaxError{newErrorBase("Compile Error: syntax-error: ", form, format, a...).at(form)}, false)
+}
+
+func compileError(form Object, format string, a ...interface{}) {
+	raise(&SyntaxError{newErrorBase("Compile Error: ", form, format, a...).at(form)}, false)
+}
+
+func arityError(arguments Object, format string, a ...interface{}) {
+	raise(&ArityError{newErrorBase("Compile Error: ", arguments, format, a...)}, false)
+}
+
+func typeError(object Object, format string, a ...interface{}) {
+	raise(&TypeError{newErrorBase("Compile Error: ", object, format, a...)}, false)
+}
+
+func unboundVariableError(variable *Variable) {
+	raise(&UnboundVariableError{newErrorBase("", variable, "Unbound variable: %s", variable).at(variable)}, false)
+}
+
+func runtimeError(format string, a ...interface{}) {
+	raise(&RuntimeError{newErrorBase("", nil, format, a...)}, false)
+}
+
+// Call the current exception handler with the raised object.
+// The handler is called with the outer handler installed.
+// When the object is raised by non-continuable way and the handler returns,
+// secondary exception is raised to the outer handler.
+func raise(object Object, continuable bool) Object {
+	if len(running.exceptionHandlers) == 0 {
+		if err, ok := object.(Error); ok {
+			panic(err)
+		}
+		panic(&RaiseError{newErrorBase("", object, "unhandled exception: %s", object)})
+	}
+
+	handlers := running.exceptionHandlers
+	running.exceptionHandlers = handlers[:len(handlers)-1]
+	defer func() { running.exceptionHandlers = handlers }()
+
+	result := applyProcedure(handlers[len(handlers)-1], object)
+	if !continuable {
+		raise(&RuntimeError{newErrorBase("", object, "exception handler returned from non-continuable exception: %s", object)}, false)
+	}
+	return result
+}
+
+// Call function with the handler installed as the current exception handler.
+// A panic of Go runtime, such as failed type assertion, is converted into
+// error and passed to the handler, instead of escaping from scheme program.
+func withExceptionHandler(handler Object, function func() Object) (result Object) {
+	handlers := running.exceptionHandlers
+	installed := append(handlers[:len(handlers):len(handlers)], handler)
+	running.exceptionHandlers = installed
+	defer func() { running.exceptionHandlers = handlers }()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if !isRuntimePanic(recovered) {
+				panic(recovered)
+			}
+			running.exceptionHandlers = installed
+			result = raise(&RuntimeError{newErrorBase("", nil, "%v", recovered)}, false)
+		}
+	}()
+	return function()
+}
+
+// Returns true when the panic is not raised by the interpreter, which is
+// neither an error passed to handlers nor an escape to continuation.
+func isRuntimePanic(recovered interface{}) bool {
+	switch recovered.(type) {
+	case Error, *continuationEscape:
+		return false
+	}
+	return true
+}
+
+// The error is positioned at the innermost application being evaluated.
+func newErrorBase(prefix string, object Object, format string, a ...interface{}) ErrorBase {
+	var position *scanner.Position
+	if len(running.callStack) > 0 {
+		position = running.callStack[len(running.callStack)-1].Position()
+	}
+	return ErrorBase{
+		ObjectBase: ObjectBase{position: position},
+		prefix:     prefix,
+		message:    fmt.Sprintf(format, a...),
+		object:     object,
+		stackTrace: stackTrace(),
+	}
+}
+
+// Position the error at the object in source code, such as malformed syntax.
+func (e ErrorBase) at(object Object) ErrorBase {
+	if object != nil && object.Position() != nil {
+		e.position = object.Position()
+	}
+	return e
+}
+
+// Convert a value recovered from panic into error.
+func recoveredError(recovered interface{}) error {
+	switch recovered.(type) {
+	case error:
+		return recovered.(error)
+	default:
+		return &RuntimeError{newErrorBase("", nil, "%v", recovered)}
+	}
+}
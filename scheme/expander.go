@@ -0,0 +1,351 @@
+// Expander expands macro uses in AST before evaluation.
+// It walks the tree and replaces each use of macro with the form transcribed
+// by the macro, until no macro use remains.
+// Scopes of binding forms are tracked, so that a variable which shadows
+// a macro keyword is not expanded, and macros defined by let-syntax,
+// letrec-syntax or internal define-syntax are visible only in their bodies.
+
+package scheme
+
+// The maximum nesting of macro expansions, which stops a macro
+// expanding to its use forever.
+const maxExpansionDepth = 10000
+
+// Expander is a struction for macro expansion.
+type expander struct {
+	// Macros and variables bound in local scopes, where variables are nil.
+	scopes []Binding
+	// The part of the form which is not in each scope, such as transformers of let-syntax.
+	outsides []Object
+	// The number of macro expansions which are being expanded.
+	depth int
+}
+
+// Expand macro uses in the object, and returns the expanded object.
+func expand(object Object) Object {
+	e := &expander{}
+	return e.expand(object)
+}
+
+func (e *expander) expand(object Object) Object {
+	application, ok := object.(*Application)
+	if !ok {
+		return object
+	}
+
+	variable, ok := application.procedure.(*Variable)
+	if !ok {
+		e.expandApplication(application)
+		return application
+	}
+	keyword := e.lookup(variable)
+	if macro, ok := keyword.(*Macro); ok {
+		if e.depth >= maxExpansionDepth {
+			syntaxError(application, "macro expansion is nested too deeply: %s", application)
+		}
+		e.depth++
+		expansion := e.expand(macro.transform(application))
+		e.depth--
+		return expansion
+	}
+
+	switch keyword {
+	case builtinSyntaxes["quote"], builtinSyntaxes["syntax-rules"], builtinSyntaxes["define-record-type"]:
+	case builtinSyntaxes["quasiquote"]:
+		e.expandQuasiquote(argumentAt(application, 0), 1)
+	case builtinSyntaxes["lambda"]:
+		e.pushScope(formIdentifiers(argumentAt(application, 0))...)
+		e.expandArguments(application, 1)
+		e.popScope()
+	case builtinSyntaxes["let"], builtinSyntaxes["let-values"]:
+		e.expandLet(application, parallelScope)
+	case builtinSyntaxes["let*"], builtinSyntaxes["let*-values"]:
+		e.expandLet(application, sequentialScope)
+	case builtinSyntaxes["letrec"], builtinSyntaxes["letrec*"]:
+		e.expandLet(application, recursiveScope)
+	case builtinSyntaxes["do"]:
+		e.expandDo(application)
+	case builtinSyntaxes["define"]:
+		e.expandDefine(application)
+	case builtinSyntaxes["guard"]:
+		e.expandArguments(application, 1)
+		if clauses, ok := argumentAt(application, 0).(*Application); ok {
+			e.pushScope(clauses.procedure)
+			e.expandArguments(clauses, 0)
+			e.popScope()
+		}
+	case builtinSyntaxes["define-syntax"]:
+		e.defineSyntax(application)
+	case builtinSyntaxes["define-macro"]:
+		name, transformer := macroDefinition(application)
+		e.bindMacro(application, name, evalMacroTransformer(application, transformer))
+	case builtinSyntaxes["let-syntax"]:
+		// Templates of transformers are outside of the scope of keywords.
+		e.expandSyntaxBindings(application)
+		e.outsides[len(e.outsides)-1] = argumentAt(application, 0)
+		e.expandArguments(application, 1)
+		e.popScope()
+	case builtinSyntaxes["letrec-syntax"]:
+		e.expandSyntaxBindings(application)
+		e.expandArguments(application, 1)
+		e.popScope()
+	default:
+		e.expandApplication(application)
+	}
+	return application
+}
+
+// Returns the bound macro or syntax, or nil for variables.
+// Renamed variable which is not bound refers to the original variable,
+// which is looked up in the same way as the frame of the environment.
+func (e *expander) lookup(variable *Variable) Object {
+	frame, bound := running.environment.lookup(variable)
+	for target := variable; target != nil; target = target.original {
+		for i := len(e.scopes) - 1; i >= 0; i-- {
+			if object, ok := e.scopes[i][target.identifier]; ok && !isWithin(target, e.outsides[i]) {
+				return object
+			}
+		}
+		if target == bound && target != variable {
+			return frame.get(target.identifier)
+		} else if target == bound {
+			break
+		}
+	}
+	return variable.resolve()
+}
+
+func (e *expander) pushScope(variables ...Object) Binding {
+	scope := Binding{}
+	for _, variable := range variables {
+		if variable.isVariable() {
+			scope[variable.(*Variable).identifier] = nil
+		}
+	}
+	e.scopes = append(e.scopes, scope)
+	e.outsides = append(e.outsides, nil)
+	return scope
+}
+
+func (e *expander) popScope() {
+	e.scopes = e.scopes[:len(e.scopes)-1]
+	e.outsides = e.outsides[:len(e.outsides)-1]
+}
+
+func (e *expander) expandApplication(application *Application) {
+	application.procedure = e.expand(application.procedure)
+	e.expandArguments(application, 0)
+}
+
+// Expand arguments of the application from the index.
+func (e *expander) expandArguments(application *Application, from int) {
+	index := 0
+	for pair := application.arguments; pair.isPair(); pair = pair.(*Pair).Cdr {
+		if index >= from {
+			pair.(*Pair).Car = e.expand(pair.(*Pair).Car)
+		}
+		index++
+	}
+}
+
+// Expand unquoted expressions in the template of quasiquote.
+func (e *expander) expandQuasiquote(template Object, depth int) {
+	switch template.(type) {
+	case *Application:
+		application := template.(*Application)
+		if isSyntaxApplication(application, "unquote") || isSyntaxApplication(application, "unquote-splicing") {
+			if depth == 1 {
+				e.expandArguments(application, 0)
+				return
+			}
+			depth--
+		} else if isSyntaxApplication(application, "quasiquote") {
+			depth++
+		}
+
+		e.expandQuasiquote(application.procedure, depth)
+		tail := application.arguments
+		for ; tail.isPair(); tail = tail.(*Pair).Cdr {
+			e.expandQuasiquote(tail.(*Pair).Car, depth)
+		}
+		e.expandQuasiquote(tail, depth)
+	case *Vector:
+		// Elements of vector are data, which are converted to forms for
+		// unquoted expressions in them.
+		vector := template.(*Vector)
+		for index, element := range vector.elements {
+			form := datumToForm(element)
+			if form != Null && !form.isSymbol() {
+				form.setParent(vector)
+			}
+			vector.elements[index] = form
+			e.expandQuasiquote(form, depth)
+		}
+	}
+}
+
+// Scopes of variables bound by let family, where inits are expanded.
+const (
+	// Inits are outside of the scope of all variables, such as let.
+	parallelScope = iota
+	// Each init is in the scope of the preceding variables, such as let*.
+	sequentialScope
+	// Inits are in the scope of all variables, such as letrec.
+	recursiveScope
+)
+
+// (let [name] ((variable init) ...) body ...)
+// Variables of let-values are formals, such as (a b . rest).
+func (e *expander) expandLet(application *Application, scoping int) {
+	variables := []Object{}
+	bindingsIndex := 0
+	if name := argumentAt(application, 0); name != nil && name.isVariable() {
+		variables = append(variables, name)
+		bindingsIndex = 1
+	}
+	bindings, _ := formElements(argumentAt(application, bindingsIndex))
+	for _, binding := range bindings {
+		if elements, ok := formElements(binding); ok && len(elements) > 0 {
+			variables = append(variables, formIdentifiers(elements[0])...)
+		}
+	}
+
+	switch scoping {
+	case recursiveScope:
+		e.pushScope(variables...)
+	case sequentialScope:
+		e.pushScope()
+	}
+	for _, binding := range bindings {
+		if binding.isApplication() {
+			e.expandArguments(binding.(*Application), 0)
+			if elements, ok := formElements(binding); scoping == sequentialScope && ok {
+				for _, variable := range formIdentifiers(elements[0]) {
+					if variable.isVariable() {
+						e.declare(variable.(*Variable).identifier, nil)
+					}
+				}
+			}
+		}
+	}
+	if scoping == parallelScope {
+		e.pushScope(variables...)
+	}
+	e.expandArguments(application, bindingsIndex+1)
+	e.popScope()
+}
+
+// (do ((variable init [step]) ...) (test expression ...) body ...)
+func (e *expander) expandDo(application *Application) {
+	variables := []Object{}
+	bindings, _ := formElements(argumentAt(application, 0))
+	for _, binding := range bindings {
+		if binding.isApplication() {
+			variables = append(variables, binding.(*Application).procedure)
+			if init, ok := binding.(*Application).arguments.(*Pair); ok && !init.isNull() {
+				init.Car = e.expand(init.Car)
+			}
+		}
+	}
+
+	e.pushScope(variables...)
+	for _, binding := range bindings {
+		if binding.isApplication() {
+			e.expandArguments(binding.(*Application), 1)
+		}
+	}
+	if test, ok := argumentAt(application, 1).(*Application); ok {
+		e.expandApplication(test)
+	}
+	e.expandArguments(application, 2)
+	e.popScope()
+}
+
+// (define variable expression) declares the variable in the current scope.
+func (e *expander) expandDefine(application *Application) {
+	if variable, ok := argumentAt(application, 0).(*Variable); ok {
+		e.declare(variable.identifier, nil)
+		e.expandArguments(application, 1)
+	}
+}
+
+// (define-syntax keyword transformer) binds the macro before evaluation.
+func (e *expander) defineSyntax(application *Application) {
+	elements, _ := formElements(application)
+	if len(elements) != 3 || !elements[1].isVariable() {
+		return
+	}
+	e.bindMacro(application, elements[1].(*Variable), evalTransformer(application, elements[2]))
+}
+
+// (let-syntax ((keyword transformer) ...) body ...) pushes the scope
+// where the macros are bound, which is popped by the caller.
+func (e *expander) expandSyntaxBindings(application *Application) {
+	scope := e.pushScope()
+	bindings, _ := formElements(argumentAt(application, 0))
+	for _, binding := range bindings {
+		if elements, ok := formElements(binding); ok && len(elements) == 2 && elements[0].isVariable() {
+			scope[elements[0].(*Variable).identifier] = evalTransformer(application, elements[1])
+		}
+	}
+}
+
+// Macro defined at top level is bound in the global scope, so that it is
+// visible in the following top level forms.
+func (e *expander) bindMacro(form Object, name *Variable, macro *Macro) {
+	if len(e.scopes) == 0 {
+		running.environment.define(name.identifier, macro)
+	} else {
+		e.declare(name.identifier, macro)
+	}
+}
+
+func (e *expander) declare(identifier string, object Object) {
+	if len(e.scopes) > 0 {
+		e.scopes[len(e.scopes)-1][identifier] = object
+	}
+}
+
+// Returns the argument of the application at the index,
+// or nil when it does not exist.
+func argumentAt(application *Application, index int) Object {
+	for pair := application.arguments; pair.isPair(); pair = pair.(*Pair).Cdr {
+		if index == 0 {
+			return pair.(*Pair).Car
+		}
+		index--
+	}
+	return nil
+}
+
+// Returns variables in the form, which is a variable or a list of them
+// such as (a b) and (a b . rest).
+func formIdentifiers(form Object) []Object {
+	variables := []Object{}
+	tail := form
+	if form != nil && form.isApplication() {
+		variables = append(variables, form.(*Application).procedure)
+		tail = form.(*Application).arguments
+	}
+	for ; tail != nil && tail.isPair(); tail = tail.(*Pair).Cdr {
+		variables = append(variables, tail.(*Pair).Car)
+	}
+	if tail != nil && tail.isVariable() {
+		variables = append(variables, tail)
+	}
+	return variables
+}
+
+// Expand the form once when it is a macro use, and returns
+// false as second value when it is not.
+func expandMacroUse(form Object) (Object, bool) {
+	application, ok := form.(*Application)
+	if !ok || !application.procedure.isVariable() {
+		return form, false
+	}
+	macro, ok := (&expander{}).lookup(application.procedure.(*Variable)).(*Macro)
+	if !ok {
+		return form, false
+	}
+	return macro.transform(application), true
+}
@@ -0,0 +1,58 @@
+// Frame is a record of an application in call stack.
+// Application.applyProcedure() pushes a frame while it is evaluated,
+// and an error takes a snapshot of the call stack as its stack trace.
+
+package scheme
+
+import (
+	"fmt"
+	"text/scanner"
+)
+
+// Frame is a struction for an application in call stack.
+type Frame struct {
+	application *Application
+	bounder     *Variable // bounder of the applied procedure
+}
+
+// Name returns the name of the applied procedure.
+func (f Frame) Name() string {
+	if f.bounder != nil {
+		return f.bounder.identifier
+	}
+	return f.application.procedure.String()
+}
+
+// Arguments returns the arguments of the application as written in source.
+func (f Frame) Arguments() Object {
+	return f.application.arguments
+}
+
+// Position returns the position of the application in source code.
+func (f Frame) Position() *scanner.Position {
+	return f.application.Position()
+}
+
+func (f Frame) String() string {
+	text := fmt.Sprintf("(%s", f.Name())
+	if arguments, ok := f.Arguments().(*Pair); ok && arguments.isList() {
+		for _, argument := range arguments.Elements() {
+			text += " " + argument.String()
+		}
+	}
+	text += ")"
+
+	if f.Position() != nil {
+		return fmt.Sprintf("%s at %s", text, f.Position())
+	}
+	return text
+}
+
+// Returns a copy of call stack, the innermost frame comes first.
+func stackTrace() []Frame {
+	frames := make([]Frame, len(running.callStack))
+	for i, frame := range running.callStack {
+		frames[len(running.callStack)-1-i] = frame
+	}
+	return frames
+}
@@ -0,0 +1,185 @@
+// HashTable is a type for scheme hash table, which is backed by Go's map.
+// Keys are compared by one of eq?, eqv?, equal? and string=?.
+// Each key is converted to a key of Go's map, so that keys which are
+// the same by the comparator have the same map key.
+
+package scheme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Equality procedures which can be given to make-hash-table as comparator.
+var hashTableComparators map[Object]string
+
+func init() {
+	hashTableComparators = map[Object]string{}
+	for _, name := range []string{"eq?", "eqv?", "equal?", "string=?"} {
+		hashTableComparators[builtinProcedure[name]] = name
+	}
+}
+
+// HashTable is a struction for scheme hash table.
+type HashTable struct {
+	ObjectBase
+	comparator string
+	entries    map[interface{}]*hashEntry
+	sequence   int
+}
+
+// An association of hash table. sequence is used to list
+// associations in the inserted order.
+type hashEntry struct {
+	key      Object
+	value    Object
+	sequence int
+}
+
+// NewHashTable is a function for definition a new HashTable object.
+func NewHashTable(comparator string) *HashTable {
+	return &HashTable{comparator: comparator, entries: map[interface{}]*hashEntry{}}
+}
+
+// Eval is hash table's eval IF.
+func (h *HashTable) Eval() Object {
+	return h
+}
+
+func (h *HashTable) String() string {
+	return fmt.Sprintf("#<hash-table %s>", h.comparator)
+}
+
+func (h *HashTable) lookup(key Object) (Object, bool) {
+	if entry, ok := h.entries[h.hashKey(key)]; ok {
+		return entry.value, true
+	}
+	return nil, false
+}
+
+func (h *HashTable) store(key Object, value Object) {
+	hashKey := h.hashKey(key)
+	if entry, ok := h.entries[hashKey]; ok {
+		entry.value = value
+		return
+	}
+	h.sequence++
+	h.entries[hashKey] = &hashEntry{key: key, value: value, sequence: h.sequence}
+}
+
+func (h *HashTable) remove(key Object) bool {
+	hashKey := h.hashKey(key)
+	if _, ok := h.entries[hashKey]; !ok {
+		return false
+	}
+	delete(h.entries, hashKey)
+	return true
+}
+
+// Returns associations in the inserted order.
+func (h *HashTable) sortedEntries() []*hashEntry {
+	entries := []*hashEntry{}
+	for _, entry := range h.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].sequence < entries[j].sequence })
+	return entries
+}
+
+func (h *HashTable) hashKey(key Object) interface{} {
+	switch h.comparator {
+	case "string=?":
+		assertObjectType(key, "string")
+		return key.(*String).text
+	case "equal?":
+		return equalHashKey(key)
+	default:
+		return eqvHashKey(key)
+	}
+}
+
+// Numbers, booleans and characters are compared by their values,
+// and other objects are compared by their identities.
+func eqvHashKey(object Object) interface{} {
+	switch object.(type) {
+	case *Number:
+		return "number:" + object.String()
+	case *Boolean:
+		return object.(*Boolean).value
+	case *Char:
+		return object.(*Char).value
+	case *Pair:
+		if object.isNull() {
+			return "()"
+		}
+	}
+	return object
+}
+
+// Lists, vectors, strings and bytevectors are compared by their contents.
+func equalHashKey(object Object) interface{} {
+	switch object.(type) {
+	case *Pair, *Vector, *String, *Bytevector:
+		return "equal:" + equalHashText(object)
+	}
+	return eqvHashKey(object)
+}
+
+// Returns the text which is unique for the contents of the object.
+// The object which has identity, such as symbol, is written with its
+// address, and so is the pair or vector which appears in itself, such as
+// a circular list.
+func equalHashText(object Object) string {
+	text := &strings.Builder{}
+	writeEqualHashText(text, object, map[Object]bool{})
+	return text.String()
+}
+
+// Pairs and vectors being written are in the path. A list is written
+// along its cdrs without recursion, so that a long list is also hashed.
+func writeEqualHashText(text *strings.Builder, object Object, path map[Object]bool) {
+	if path[object] {
+		fmt.Fprintf(text, "#<%p>", object)
+		return
+	}
+	switch object.(type) {
+	case *Pair:
+		if object.isNull() {
+			text.WriteString("()")
+			return
+		}
+		pairs := []Object{}
+		for pair, ok := object.(*Pair); ok && !pair.isNull() && !path[pair]; pair, ok = pair.Cdr.(*Pair) {
+			path[pair] = true
+			pairs = append(pairs, pair)
+			text.WriteString("(")
+			writeEqualHashText(text, pair.Car, path)
+			text.WriteString(" . ")
+			object = pair.Cdr
+		}
+		writeEqualHashText(text, object, path)
+		for _, pair := range pairs {
+			text.WriteString(")")
+			delete(path, pair)
+		}
+	case *Vector:
+		path[object] = true
+		text.WriteString("#(")
+		for index, element := range object.(*Vector).elements {
+			if index > 0 {
+				text.WriteString(" ")
+			}
+			writeEqualHashText(text, element, path)
+		}
+		text.WriteString(")")
+		delete(path, object)
+	case *String, *Bytevector, *Number, *Boolean, *Char:
+		// The atom is tagged with its type and length, so that it is not
+		// confused with other types or the structure of lists.
+		atom := object.String()
+		fmt.Fprintf(text, "%s:%d:%s", typeName(object), len(atom), atom)
+	default:
+		fmt.Fprintf(text, "#<%p>", object)
+	}
+}
@@ -6,28 +6,61 @@ package scheme
 
 import (
 	"fmt"
+	"gosc/vm"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/scanner"
 )
 
+// DefaultBacktraceDepth is the number of frames printed on error by default.
+const DefaultBacktraceDepth = 20
+
 // Interpreter is a struction for interpreter.
 type Interpreter struct {
 	*Parser
-	closure *Closure
+	environment    *Environment
+	context        *context
+	backtraceDepth int
+	compiled       bool // whether expressions are compiled for the virtual machine
+}
+
+// Context is the state of evaluation, which is owned by each interpreter.
+type context struct {
+	// The frame where expressions are evaluated now.
+	// Each evaluation restores it before returning, so that the caller
+	// continues in its own frame. TailCall holds the frame where it is
+	// evaluated later.
+	environment *Environment
+	// Frames of applications being evaluated, the last one is the innermost.
+	callStack []Frame
+	// Stack of exception handlers, the last one is the current handler.
+	exceptionHandlers []Object
+	// The machine which runs compiled code. It is created by the first
+	// run, and reused by compiled closures called from the evaluator.
+	machine *vm.Machine
 }
 
+var (
+	// The context of the interpreter which is evaluating now. While no
+	// interpreter is evaluating, such as parsing source, the idle context
+	// without handlers is used.
+	running = &context{}
+	// Interpreters evaluate one at a time, because the running context is
+	// referred by evaluation.
+	evaluation sync.Mutex
+)
+
 // NewInterpreter is a struction for definition of new interpreter.
 func NewInterpreter(source string) *Interpreter {
 	i := &Interpreter{
-		Parser: NewParser(source),
-		closure: &Closure{
-			ObjectBase:   ObjectBase{parent: nil},
-			localBinding: DefaultBinding(),
-		},
+		Parser:         NewParser(source),
+		environment:    NewTopLevelEnvironment(DefaultBinding()),
+		context:        &context{},
+		backtraceDepth: DefaultBacktraceDepth,
 	}
 	i.loadBuiltinLibrary("builtin")
 	return i
@@ -38,39 +71,132 @@ func (i *Interpreter) ReloadSourceCode(source string) {
 	i.Parser = NewParser(source)
 }
 
+// SetBacktraceDepth sets the max number of frames printed on error.
+// When depth is 0, stack trace is not printed.
+func (i *Interpreter) SetBacktraceDepth(depth int) {
+	i.backtraceDepth = depth
+}
+
+// SetVM sets whether expressions are compiled and run on the virtual machine.
+// The builtin library is loaded again, so that its procedures are compiled too.
+func (i *Interpreter) SetVM(compiled bool) {
+	i.compiled = compiled
+	i.loadBuiltinLibrary("builtin")
+}
+
 // PrintResult is a function to print result of Eval.
+// When an error is raised, its stack trace is printed after the message.
 func (i *Interpreter) PrintResult(dumpAST bool) {
-	results := i.EvalSource(dumpAST)
+	results, err := i.evalAll(dumpAST)
 	if dumpAST {
 		fmt.Printf("\n*** Result ***\n")
 	}
 	for _, result := range results {
 		fmt.Println(result)
 	}
+	if err != nil {
+		fmt.Printf("*** ERROR: %s\n", err)
+		i.printStackTrace(err)
+	}
 }
 
 // EvalSource is a struction to eval on interpreter.
-func (i *Interpreter) EvalSource(dumpAST bool) (results []string) {
-	defer func() {
-		if err := recover(); err != nil {
-			results = append(results, fmt.Sprintf("*** ERROR: %s", err))
+// Evaluation stops at the first error, which is printed as the last result.
+func (i *Interpreter) EvalSource(dumpAST bool) []string {
+	results, err := i.evalAll(dumpAST)
+	if err != nil {
+		return append(results, fmt.Sprintf("*** ERROR: %s", err))
+	}
+	return results
+}
+
+// Eval all expressions in source code until an error is raised.
+func (i *Interpreter) evalAll(dumpAST bool) (results []string, err error) {
+	for i.Peek() != scanner.EOF {
+		result, err := i.evalNext(dumpAST)
+		if err != nil {
+			return results, err
 		}
-	}()
+		if result == nil {
+			break
+		}
+		results = append(results, result.String())
+	}
+	return results, nil
+}
+
+func (i *Interpreter) printStackTrace(err error) {
+	schemeError, ok := err.(Error)
+	if !ok || i.backtraceDepth <= 0 || len(schemeError.StackTrace()) == 0 {
+		return
+	}
 
+	fmt.Println("Stack Trace:")
+	for index, frame := range schemeError.StackTrace() {
+		if index >= i.backtraceDepth {
+			fmt.Printf("  ... %d more frames\n", len(schemeError.StackTrace())-index)
+			break
+		}
+		fmt.Printf("%3d  %s\n", index, frame)
+	}
+}
+
+// Eval evaluates all expressions in source code and returns the last result.
+// An error raised by scheme program is returned as Error,
+// which is distinguished by its type such as *TypeError.
+func (i *Interpreter) Eval() (result Object, err error) {
 	for i.Peek() != scanner.EOF {
-		expression := i.Parser.Parse(i.closure)
-		if dumpAST {
-			fmt.Printf("\n*** AST ***\n")
-			i.DumpAST(expression, 0)
-			fmt.Printf("\n*** Result ***\n")
+		object, err := i.evalNext(false)
+		if err != nil {
+			return nil, err
 		}
+		if object == nil {
+			break
+		}
+		result = object
+	}
+	return result, nil
+}
 
-		if expression == nil {
-			return
+// Parse and eval next expression in source code.
+// The error is recovered in the context of this interpreter, which is
+// running until this method returns.
+func (i *Interpreter) evalNext(dumpAST bool) (result Object, err error) {
+	evaluation.Lock()
+	idle := running
+	running = i.context
+	defer func() {
+		running = idle
+		evaluation.Unlock()
+	}()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recoveredError(recovered)
+		}
+	}()
+
+	running.environment = i.environment
+	expression := expand(i.Parser.ParseForm())
+	analyze(expression, running.environment)
+	var code *vm.Code
+	if expression != nil && i.compiled {
+		code = compile(expression, running.environment)
+	}
+	if dumpAST {
+		fmt.Printf("\n*** AST ***\n")
+		i.DumpAST(expression, 0)
+		if code != nil {
+			fmt.Printf("\n*** Code ***\n%s\n", code)
 		}
-		results = append(results, expression.Eval().String())
+		fmt.Printf("\n*** Result ***\n")
 	}
-	return
+
+	if expression == nil {
+		return nil, nil
+	} else if code != nil {
+		return execute(code, running.environment), nil
+	}
+	return expression.Eval(), nil
 }
 
 // DumpAST is a defining of dumping abstrct tree.
@@ -80,7 +206,7 @@ func (i *Interpreter) DumpAST(object Object, indentLevel int) {
 	}
 	switch object.(type) {
 	case *Application:
-		i.printWithIndent("Application", indentLevel)
+		i.printWithIndent("Application", object, indentLevel)
 		i.DumpAST(object.(*Application).procedure, indentLevel+1)
 		i.DumpAST(object.(*Application).arguments, indentLevel+1)
 	case *Pair:
@@ -88,25 +214,31 @@ func (i *Interpreter) DumpAST(object Object, indentLevel int) {
 		if pair.Car == nil && pair.Cdr == nil {
 			return
 		}
-		i.printWithIndent("Pair", indentLevel)
+		i.printWithIndent("Pair", object, indentLevel)
 		i.DumpAST(pair.Car, indentLevel+1)
 		i.DumpAST(pair.Cdr, indentLevel+1)
 	case *String:
-		i.printWithIndent(fmt.Sprintf("String(%s)", object), indentLevel)
+		i.printWithIndent(fmt.Sprintf("String(%s)", object), object, indentLevel)
 	case *Number:
-		i.printWithIndent(fmt.Sprintf("Number(%s)", object), indentLevel)
+		i.printWithIndent(fmt.Sprintf("Number(%s)", object), object, indentLevel)
 	case *Boolean:
-		i.printWithIndent(fmt.Sprintf("Boolean(%s)", object), indentLevel)
+		i.printWithIndent(fmt.Sprintf("Boolean(%s)", object), object, indentLevel)
+	case *Char:
+		i.printWithIndent(fmt.Sprintf("Char(%s)", object), object, indentLevel)
+	case *Vector:
+		i.printWithIndent(fmt.Sprintf("Vector(%s)", object), object, indentLevel)
+	case *Bytevector:
+		i.printWithIndent(fmt.Sprintf("Bytevector(%s)", object), object, indentLevel)
 	case *Variable:
-		i.printWithIndent(fmt.Sprintf("Variable(%s)", object.(*Variable).identifier), indentLevel)
-	case *Procedure:
-		i.printWithIndent("Procedure", indentLevel)
-		i.DumpAST(object.(*Procedure).arguments, indentLevel+1)
-		i.DumpAST(object.(*Procedure).body, indentLevel+1)
+		i.printWithIndent(fmt.Sprintf("Variable(%s)", object.(*Variable).identifier), object, indentLevel)
 	}
 }
 
-func (i *Interpreter) printWithIndent(text string, indentLevel int) {
+// Print text with the object's position in source code.
+func (i *Interpreter) printWithIndent(text string, object Object, indentLevel int) {
+	if object.Position() != nil {
+		text = fmt.Sprintf("%s [%s]", text, object.Position())
+	}
 	fmt.Printf("%s%s\n", strings.Repeat(" ", indentLevel), text)
 }
 
@@ -114,6 +246,7 @@ func (i *Interpreter) loadBuiltinLibrary(name string) {
 	originalParser := i.Parser
 	stringbuffer := i.readLibraryPath(name)
 	i.Parser = NewParser(stringbuffer)
+	i.SetFilename(name + ".scm")
 	i.EvalSource(false)
 	i.Parser = originalParser
 }
@@ -146,15 +279,3 @@ func (i *Interpreter) readLibraryPath(name string) string {
 	}
 	return string(buffer)
 }
-
-func syntaxError(format string, a ...interface{}) {
-	compileError("syntax-error: "+format, a...)
-}
-
-func compileError(format string, a ...interface{}) {
-	runtimeError("Compile Error: "+format, a...)
-}
-
-func runtimeError(format string, a ...interface{}) {
-	panic(fmt.Sprintf(format, a...))
-}
@@ -1,9 +1,13 @@
 package scheme
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -45,9 +49,141 @@ var interpreterTests = []interpreterTest{
 	evalTest("(quote #f)", "#f"),
 	evalTest("(quote #t)", "#t"),
 	evalTest("(quote  ( 1 (3) 4 ))", "(1 (3) 4)"),
+	evalTest("(car (cadr '(a 'b))) (cadr ''a) '(\"a b\" (c \"d\"))", "quote", "a", "(\"a b\" (c \"d\"))"),
+	evalTest("(define f (lambda () '(1 2))) (eq? (f) (f))", "f", "#t"),
+	evalTest("'(a -name b) '(a 1+ b) '(a -1+ b) '(a 1/0 b)", "(a -name b)", "(a 1+ b)", "(a -1+ b)", "(a 1/0 b)"),
+	evalTest("(define -inc 5) -inc (symbol? '-inc)", "-inc", "5", "#t"),
+
+	evalTest("`(1 ,(+ 1 1) ,@(list 3 4))", "(1 2 3 4)"),
+	evalTest("(define x 5) `(1 . ,x) `((,x) . ,(+ x 1))", "x", "(1 . 5)", "((5) . 6)"),
+	evalTest("`(1 ,@'() 2) `(1 ,@(list 2 3) . 4) `x `()", "(1 2)", "(1 2 3 . 4)", "x", "()"),
+	evalTest("(define x 5) `#(1 ,x ,@(list 2 3)) `(#(,x))", "x", "#(1 5 2 3)", "(#(5))"),
+	evalTest("(define x 5) `#((a ,x) 'b \"c\")", "x", "#((a 5) 'b \"c\")"),
+	evalTest("(define x 5) `(a `(b ,(c ,x))) `(a `(b ,,x)) `(a `(b ,@,@(list 'x)))", "x", "(a `(b ,(c 5)))", "(a `(b ,5))", "(a `(b ,@x))"),
+	evalTest("(quasiquote (1 (unquote (+ 1 1)) (unquote-splicing (list 3)))) '`(a ,b ,@c)", "(1 2 3)", "`(a ,b ,@c)"),
+	evalTest("(let ((y 3)) `(,y ,@(list y y)))", "(3 3 3)"),
+	evalTest("(define-macro (my-if c a b) `(cond (,c ,a) (else ,b))) (my-if #f 1 2)", "my-if", "2"),
 
 	evalTest("\"\"", "\"\""),
 	evalTest("\"hello\"", "\"hello\""),
+	evalTest("\"a\\\"b\" \"a\\\\b\" \"a\\nb\" \"\\t\" \"\\x41;\\x3bb;\"", "\"a\\\"b\"", "\"a\\\\b\"", "\"a\\nb\"", "\"\\t\"", "\"Aλ\""),
+	evalTest("\"a\nb\" \"a\\\n   b\"", "\"a\\nb\"", "\"ab\""),
+	evalTest("'\"a\" '(\"a\\\"b\" #\\a)", "\"a\"", "(\"a\\\"b\" #\\a)"),
+	evalTest("(string-length \"\") (string-length \"abc\") (string-length \"λx\")", "0", "3", "2"),
+	evalTest("(string-ref \"abc\" 1) (string-ref \"λx\" 0)", "#\\b", "#\\λ"),
+	evalTest("(substring \"hello\" 1 3) (string-copy \"hello\" 2) (string-copy \"hello\")", "\"el\"", "\"llo\"", "\"hello\""),
+	evalTest("(define s (make-string 3 #\\a)) (string-set! s 1 #\\λ) s", "s", "#<undef>", "\"aλa\""),
+	evalTest("(define s (string #\\a #\\b #\\c)) (string-fill! s #\\z 1) s (string-fill! s #\\x) s", "s", "#<undef>", "\"azz\"", "#<undef>", "\"xxx\""),
+	evalTest("(define s \"abc\") (define t (string-copy s)) (string-set! t 0 #\\x) s t", "s", "t", "#<undef>", "\"abc\"", "\"xbc\""),
+	evalTest("(string=? \"abc\" \"abc\" \"abc\") (string=? \"abc\" \"abd\")", "#t", "#f"),
+	evalTest("(string<? \"abc\" \"abd\" \"b\") (string<? \"b\" \"abc\") (string>? \"b\" \"a\") (string<=? \"a\" \"a\") (string>=? \"a\" \"b\")", "#t", "#f", "#t", "#t", "#f"),
+	evalTest("(string-ci=? \"ABC\" \"abc\") (string-ci<? \"abc\" \"ABD\") (string-ci=? \"ΛΑ\" \"λα\")", "#t", "#t", "#t"),
+	evalTest("(string-upcase \"hello λ\") (string-downcase \"HELLO Λ\") (string-foldcase \"ABC\")", "\"HELLO Λ\"", "\"hello λ\"", "\"abc\""),
+	evalTest("(string->list \"abc\") (string->list \"abc\" 1) (string->list \"\")", "(#\\a #\\b #\\c)", "(#\\b #\\c)", "()"),
+	evalTest("(list->string '(#\\a #\\λ)) (list->string '())", "\"aλ\"", "\"\""),
+	evalTest("(string-index \"hello\" #\\l) (string-index \"hello\" #\\z) (string-index \"a1b\" char-numeric?) (string-index \"hello\" #\\l 3)", "2", "#f", "1", "3"),
+	evalTest("(string-split \"a,b,,c\" #\\,) (string-split \"a::b\" \"::\") (string-split \"a b\" char-whitespace?) (string-split \"\" #\\,)", "(\"a\" \"b\" \"\" \"c\")", "(\"a\" \"b\")", "(\"a\" \"b\")", "(\"\")"),
+	evalTest("(string-join '(\"a\" \"b\" \"c\")) (string-join '(\"a\" \"b\") \", \") (string-join '())", "\"a b c\"", "\"a, b\"", "\"\""),
+	evalTest("(string-join '(\"a\" \"b\") \"/\" 'prefix) (string-join '(\"a\" \"b\") \";\" 'suffix)", "\"/a/b\"", "\"a;b;\""),
+
+	evalTest("#\\a #\\A #\\( #\\;", "#\\a", "#\\A", "#\\(", "#\\;"),
+	evalTest("#\\space #\\newline #\\tab #\\null", "#\\space", "#\\newline", "#\\tab", "#\\null"),
+	evalTest("#\\x41 #\\x3bb #\\λ #\\x", "#\\A", "#\\λ", "#\\λ", "#\\x"),
+	evalTest("'(#\\a #\\space)", "(#\\a #\\space)"),
+	evalTest("(char? #\\a) (char? \"a\") (char? 'a)", "#t", "#f", "#f"),
+	evalTest("(char->integer #\\A) (char->integer #\\λ) (integer->char 97) (integer->char 955)", "65", "955", "#\\a", "#\\λ"),
+	evalTest("(char=? #\\a #\\a #\\a) (char=? #\\a #\\b)", "#t", "#f"),
+	evalTest("(char<? #\\a #\\b #\\c) (char<? #\\a #\\c #\\b) (char>? #\\b #\\a)", "#t", "#f", "#t"),
+	evalTest("(char<=? #\\a #\\a #\\b) (char>=? #\\b #\\b #\\c)", "#t", "#f"),
+	evalTest("(char-ci=? #\\a #\\A) (char-ci<? #\\a #\\B) (char-ci=? #\\λ #\\Λ)", "#t", "#t", "#t"),
+	evalTest("(char-upcase #\\a) (char-downcase #\\A) (char-upcase #\\λ) (char-upcase #\\1)", "#\\A", "#\\a", "#\\Λ", "#\\1"),
+	evalTest("(char-foldcase #\\Σ)", "#\\σ"),
+	evalTest("(char-alphabetic? #\\a) (char-alphabetic? #\\λ) (char-alphabetic? #\\1)", "#t", "#t", "#f"),
+	evalTest("(char-numeric? #\\1) (char-numeric? #\\x0663) (char-numeric? #\\a)", "#t", "#t", "#f"),
+	evalTest("(char-whitespace? #\\space) (char-whitespace? #\\tab) (char-whitespace? #\\x3000) (char-whitespace? #\\a)", "#t", "#t", "#t", "#f"),
+	evalTest("(char-upper-case? #\\A) (char-lower-case? #\\A)", "#t", "#f"),
+	evalTest("(digit-value #\\3) (digit-value #\\x0663) (digit-value #\\a)", "3", "3", "#f"),
+	evalTest("(eq? #\\a #\\a) (equal? '(#\\a) '(#\\a))", "#t", "#t"),
+
+	evalTest("#(1 2 3) #() #(a \"b\" #\\c (1 2) #(3))", "#(1 2 3)", "#()", "#(a \"b\" #\\c (1 2) #(3))"),
+	evalTest("'#(1 2) '(#(1) 2) (quote #(a b))", "#(1 2)", "(#(1) 2)", "#(a b)"),
+	evalTest("(vector? #(1)) (vector? '(1)) (vector 1 (+ 1 1) 'a)", "#t", "#f", "#(1 2 a)"),
+	evalTest("(make-vector 3 0) (make-vector 0)", "#(0 0 0)", "#()"),
+	evalTest("(vector-length #(1 2 3)) (vector-ref #(1 2 3) 1)", "3", "2"),
+	evalTest("(define v (make-vector 2 'a)) (vector-set! v 0 'b) v", "v", "#<undef>", "#(b a)"),
+	evalTest("(vector->list #(1 2 3)) (vector->list #(1 2 3) 1) (vector->list #(1 2 3) 1 2)", "(1 2 3)", "(2 3)", "(2)"),
+	evalTest("(list->vector '(1 2)) (list->vector '())", "#(1 2)", "#()"),
+	evalTest("(define v #(1 2 3)) (define w (vector-copy v 1)) (vector-set! w 0 'x) v w", "v", "w", "#<undef>", "#(1 2 3)", "#(x 3)"),
+	evalTest("(define v (vector 1 2 3)) (vector-fill! v 0 1) v (vector-fill! v 9) v", "v", "#<undef>", "#(1 0 0)", "#<undef>", "#(9 9 9)"),
+	evalTest("(vector-map (lambda (x) (* x x)) #(1 2 3)) (vector-map + #(1 2) #(10 20 30))", "#(1 4 9)", "#(11 22)"),
+	evalTest("(define sum 0) (vector-for-each (lambda (x) (set! sum (+ sum x))) #(1 2 3)) sum", "sum", "#<undef>", "6"),
+	evalTest("(equal? #(1 (2)) #(1 (2))) (equal? #(1) #(2)) (eq? #(1) #(1))", "#t", "#f", "#f"),
+	evalTest("#u8(1 2 255) #u8() '#u8(0)", "#u8(1 2 255)", "#u8()", "#u8(0)"),
+	evalTest("(bytevector? #u8(1)) (bytevector? #(1)) (bytevector 1 2) (make-bytevector 2 7)", "#t", "#f", "#u8(1 2)", "#u8(7 7)"),
+	evalTest("(bytevector-length #u8(1 2)) (bytevector-u8-ref #u8(5 6) 1)", "2", "6"),
+	evalTest("(define b (make-bytevector 2 0)) (bytevector-u8-set! b 1 255) b", "b", "#<undef>", "#u8(0 255)"),
+	evalTest("(equal? #u8(1 2) #u8(1 2)) (equal? #u8(1) #u8(2))", "#t", "#f"),
+
+	evalTest("(define h (make-hash-table)) (hash-table? h) (hash-table? '())", "h", "#t", "#f"),
+	evalTest("(define h (make-hash-table)) (hash-table-set! h 'a 1) (hash-table-ref h 'a) (hash-table-ref/default h 'b 0)", "h", "#<undef>", "1", "0"),
+	evalTest("(define h (make-hash-table 'equal?)) (hash-table-set! h '(1 \"a\") 'x) (hash-table-ref h (list 1 \"a\"))", "h", "#<undef>", "x"),
+	evalTest("(define h (make-hash-table equal?)) (hash-table-set! h #(1 (2)) 'x \"k\" 'y) (hash-table-ref h #(1 (2))) (hash-table-ref h \"k\")", "h", "#<undef>", "x", "y"),
+	evalTest("(define l (list 1 2)) (set-cdr! (cdr l) l) (define h (make-hash-table equal?)) (hash-table-set! h l 1) (hash-table-ref h l) (hash-table-contains? h (list 1 2))", "l", "#<undef>", "h", "#<undef>", "1", "#f"),
+	evalTest("(define l (list 1 2)) (set-car! l l) (define v (vector 1)) (vector-set! v 0 v) (define h (make-hash-table equal?)) (hash-table-set! h l 'l v 'v) (hash-table-ref h l) (hash-table-ref h v)", "l", "#<undef>", "v", "#<undef>", "h", "#<undef>", "l", "v"),
+	evalTest("(define x (list 1)) (define h (make-hash-table equal?)) (hash-table-set! h (list x x) 'shared) (hash-table-ref h '((1) (1)))", "x", "h", "#<undef>", "shared"),
+	evalTest("(define h (make-hash-table equal?)) (hash-table-set! h (list (string->symbol \"1\")) 'symbol (list 1) 'number) (hash-table-ref h (list (string->symbol \"1\"))) (hash-table-ref h (list 1))", "h", "#<undef>", "symbol", "number"),
+	evalTest("(define h (make-hash-table equal?)) (hash-table-set! h (list (string->symbol \"#\\\\a\")) 'symbol (list #\\a) 'char) (hash-table-ref h (list (string->symbol \"#\\\\a\"))) (hash-table-ref h (list #\\a)) (hash-table-count h)", "h", "#<undef>", "symbol", "char", "2"),
+	evalTest("(define h (make-hash-table eq?)) (hash-table-set! h \"k\" 1) (hash-table-contains? h \"k\") (hash-table-set! h 1 'one) (hash-table-ref h 1)", "h", "#<undef>", "#f", "#<undef>", "one"),
+	evalTest("(define h (make-hash-table eqv?)) (hash-table-set! h 1 'exact 1.0 'inexact) (hash-table-ref h 1) (hash-table-ref h 1.0) (hash-table-set! h #\\a 'char) (hash-table-ref h #\\a)", "h", "#<undef>", "exact", "inexact", "#<undef>", "char"),
+	evalTest("(define h (make-hash-table string=?)) (hash-table-set! h \"a\" 1) (hash-table-ref h (string #\\a))", "h", "#<undef>", "1"),
+	evalTest("(define h (make-hash-table)) (hash-table-set! h 'a 1 'b 2) (hash-table-delete! h 'a 'c) (hash-table-contains? h 'a) (hash-table-exists? h 'b)", "h", "#<undef>", "1", "#f", "#t"),
+	evalTest("(define h (make-hash-table)) (hash-table-ref h 'a (lambda () 'none)) (hash-table-set! h 'a 1) (hash-table-ref h 'a (lambda () 'none) (lambda (x) (* x 10)))", "h", "none", "#<undef>", "10"),
+	evalTest("(define h (make-hash-table)) (hash-table-set! h 'a 1) (hash-table-update! h 'a (lambda (x) (+ x 1))) (hash-table-ref h 'a)", "h", "#<undef>", "#<undef>", "2"),
+	evalTest("(define h (make-hash-table)) (hash-table-update! h 'a (lambda (x) (+ x 1)) (lambda () 0)) (hash-table-update!/default h 'a (lambda (x) (* x 5)) 0) (hash-table-ref h 'a)", "h", "#<undef>", "#<undef>", "5"),
+	evalTest("(define h (make-hash-table)) (hash-table-set! h 'c 3 'a 1 'b 2) (hash-table-keys h) (hash-table-values h) (hash-table->alist h)", "h", "#<undef>", "(c a b)", "(3 1 2)", "((c . 3) (a . 1) (b . 2))"),
+	evalTest("(define h (make-hash-table)) (hash-table-set! h 'a 1 'b 2 'a 3) (hash-table->alist h)", "h", "#<undef>", "((a . 3) (b . 2))"),
+	evalTest("(define h (make-hash-table)) (define sum 0) (hash-table-set! h 'a 1 'b 2) (hash-table-walk h (lambda (k v) (set! sum (+ sum v)))) sum", "h", "sum", "#<undef>", "#<undef>", "3"),
+	evalTest("(define h (make-hash-table)) (hash-table-count h) (hash-table-set! h 'a 1 'b 2 'c 3) (hash-table-count h) (hash-table-count (lambda (k v) (odd? v)) h)", "h", "0", "#<undef>", "3", "2"),
+
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (make-point 1 2)", "<point>", "#<point x: 1 y: 2>"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (define p (make-point 1 '(2))) (point-x p) (point-y p) (set-point-x! p 10) p", "<point>", "p", "1", "(2)", "#<undef>", "#<point x: 10 y: (2)>"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (point? (make-point 1 2)) (point? '(1 2)) (point? 1)", "<point>", "#t", "#f", "#f"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) <point> make-point point-x", "<point>", "#<record-type point>", "#<subr make-point>", "#<subr point-x>"),
+	evalTest("(define-record-type node (make-node value) node? (value node-value) (next node-next set-node-next!)) (make-node 1)", "node", "#<node value: 1 next: #<undef>>"),
+	evalTest("(define-record-type <a> (make-a) a?) (define-record-type <b> (make-b) b?) (a? (make-b)) (a? (make-a))", "<a>", "<b>", "#f", "#t"),
+
+	evalTest("(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp))))) (define x 1) (define y 2) (swap! x y) (list x y)", "swap!", "x", "y", "1", "(2 1)"),
+	evalTest("(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp))))) (define tmp 1) (define y 2) (swap! tmp y) (list tmp y)", "swap!", "tmp", "y", "1", "(2 1)"),
+	evalTest("(define-syntax my-or (syntax-rules () ((_) #f) ((_ e) e) ((_ e r ...) (let ((t e)) (if t t (my-or r ...)))))) (my-or) (my-or #f 2 3) (let ((t 5)) (my-or #f t))", "my-or", "#f", "2", "5"),
+	evalTest("(define-syntax my-or (syntax-rules () ((_) #f) ((_ e) e) ((_ e r ...) (let ((t e)) (if t t (my-or r ...)))))) (let ((if list)) (my-or #f 1))", "my-or", "1"),
+	evalTest("(define-syntax ten (syntax-rules () ((_) 10))) (ten) (+ (ten) 1)", "ten", "10", "11"),
+	evalTest("(define-syntax my-if (syntax-rules (then else) ((_ c then t else e) (cond (c t) (else e))))) (my-if #f then 1 else 2)", "my-if", "2"),
+	evalTest("(define-syntax my-let* (syntax-rules () ((_ () body ...) (let () body ...)) ((_ ((x v) rest ...) body ...) (let ((x v)) (my-let* (rest ...) body ...))))) (my-let* ((a 1) (b (+ a 1))) (* a b))", "my-let*", "2"),
+	evalTest("(define-syntax flat (syntax-rules () ((_ (a b ...) ...) '((a ...) (b ... ...))))) (flat (1 2 3) (4 5) (6))", "flat", "((1 4 6) (2 3 5))"),
+	evalTest("(define-syntax pairs (syntax-rules () ((_ (k v ...) ...) '((k (v ...)) ...)))) (pairs (a 1 2) (b))", "pairs", "((a (1 2)) (b ()))"),
+	evalTest("(define-syntax tail (syntax-rules () ((_ a ... z) '(z a ...)))) (tail 1 2 3) (tail 1)", "tail", "(3 1 2)", "(1)"),
+	evalTest("(define-syntax vec (syntax-rules () ((_ x ...) #(x ... end)))) (vec a b)", "vec", "#(a b end)"),
+	evalTest("(define-syntax esc (syntax-rules () ((_ x) '(x (... ...))))) (esc 1)", "esc", "(1 ...)"),
+	evalTest("(define-syntax my-list (syntax-rules ::: () ((_ x :::) (list x :::)))) (my-list 1 2 3)", "my-list", "(1 2 3)"),
+	evalTest("(let-syntax ((foo (syntax-rules () ((_ x) (* x 10))))) (foo 4))", "40"),
+	evalTest("(define foo (lambda (x) x)) (let-syntax ((foo (syntax-rules () ((_ x) (* x 10))))) (foo 4)) (foo 4)", "foo", "40", "4"),
+	evalTest("(letrec-syntax ((ev? (syntax-rules () ((_ n) (if (= n 0) #t (od? (- n 1)))))) (od? (syntax-rules () ((_ n) (if (= n 0) #f #t))))) (ev? 2))", "#t"),
+	evalTest("(define f (lambda (x) 'outer)) (let-syntax ((f (syntax-rules () ((_ x) (f x))))) (f 1))", "f", "outer"),
+	evalTest("(define f (lambda (x) 'outer)) (define g (lambda () (let-syntax ((f (syntax-rules () ((_ x) (f x))))) (f 1)))) (g) (g)", "f", "g", "outer", "outer"),
+	evalTest("(define f (lambda (x) 'outer)) (letrec-syntax ((f (syntax-rules () ((_ 0) 'inner) ((_ x) (f 0))))) (f 1))", "f", "inner"),
+	evalTest("(define-syntax double (syntax-rules () ((_ x) (* 2 x)))) (define f (lambda (double) (+ double 1))) (f 3)", "double", "f", "4"),
+	evalTest("(define f (lambda () (later 2))) (define-syntax later (syntax-rules () ((_ x) (* x 3)))) (f)", "f", "later", "6"),
+
+	evalTest("(define-macro (my-when test . body) (list 'if test (cons 'begin body) #f)) (my-when (= 1 1) 1 2 3) (my-when #f 1)", "my-when", "3", "#f"),
+	evalTest("(define-macro my-unless (lambda (test . body) (list 'if test #f (cons 'begin body)))) (my-unless #f 'x)", "my-unless", "x"),
+	evalTest("(define-macro (swap! a b) (let ((tmp (gensym))) (list 'let (list (list tmp a)) (list 'set! a b) (list 'set! b tmp)))) (define x 1) (define y 2) (swap! x y) (list x y)", "swap!", "x", "y", "1", "(2 1)"),
+	evalTest("(define-macro (capture) 'it) (define it 10) (let ((it 20)) (capture))", "capture", "it", "20"),
+	evalTest("(define x 1) (eval '(+ x 2)) (eval (list 'define 'y \"z\")) y (let ((x 10)) (eval 'x))", "x", "3", "y", "\"z\"", "1"),
+	evalTest("(define-macro (my-when test . body) (list 'if test (cons 'begin body) #f)) (macroexpand-1 '(my-when a b)) (macroexpand '(my-when a b)) (macroexpand '(foo 1)) (macroexpand 'x)", "my-when", "(if a (begin b) #f)", "(if a (begin b) #f)", "(foo 1)", "x"),
+	evalTest("(define-macro (my-when test . body) (list 'if test (cons 'begin body) #f)) (define-syntax sw (syntax-rules () ((_ a b) (my-when a b)))) (macroexpand-1 '(sw 1 2)) (macroexpand '(sw 1 2))", "my-when", "sw", "(my-when 1 2)", "(if 1 (begin 2) #f)"),
+	evalTest("(symbol? (gensym)) (eq? (gensym) (gensym)) (symbol? (gensym \"tmp\"))", "#t", "#f", "#t"),
+	evalTest("((lambda (a . b) b) 1 2 3) ((lambda (a . b) b) 1) ((lambda a a) 1 2 3) ((lambda a a))", "(2 3)", "()", "(1 2 3)", "()"),
+	evalTest("'(1 . 2) '(1 2 . 3) '((1 . 2) . (3 . 4))", "(1 . 2)", "(1 2 . 3)", "((1 . 2) 3 . 4)"),
 
 	evalTest("(+)", "0"),
 	evalTest("(- 1)", "1"),
@@ -65,8 +201,76 @@ var interpreterTests = []interpreterTest{
 	evalTest("(/ 100(/ 4 2))", "50"),
 	evalTest("(+ (* 100 3) (/(- 4 2) 2))", "301"),
 
+	evalTest("1/3", "1/3"),
+	evalTest("6/4", "3/2"),
+	evalTest("3.14", "3.14"),
+	evalTest("-.5", "-0.5"),
+	evalTest("1e10", "10000000000.0"),
+	evalTest("1e21", "1.0e21"),
+	evalTest("1e-8", "1.0e-8"),
+	evalTest("#x1F #o17 #b101", "31", "15", "5"),
+	evalTest("#e1.5 #e0.1 #i1/2", "3/2", "1/10", "0.5"),
+	evalTest("+inf.0 -inf.0 +nan.0", "+inf.0", "-inf.0", "+nan.0"),
+	evalTest("'(1/2 0.5)", "(1/2 0.5)"),
+	evalTest("(/ 1 3)", "1/3"),
+	evalTest("(/ 6 3)", "2"),
+	evalTest("(+ 1/3 2/3)", "1"),
+	evalTest("(* 2/3 3/4)", "1/2"),
+	evalTest("(+ 1 0.5)", "1.5"),
+	evalTest("(- 1/2 0.5)", "0.0"),
+	evalTest("(* 1.5 2)", "3.0"),
+	evalTest("(/ 1.0 0)", "+inf.0"),
+	evalTest("(* 99999999999 99999999999)", "9999999999800000000001"),
+	evalTest("(+ 9223372036854775807 1)", "9223372036854775808"),
+	evalTest("(- -9223372036854775808 1)", "-9223372036854775809"),
+	evalTest("(- (+ 9223372036854775807 1) 1)", "9223372036854775807"),
+	evalTest("(/ 100000000000000000000 3)", "100000000000000000000/3"),
+
+	evalTest("(quotient 17 5) (remainder 17 5) (modulo 17 5)", "3", "2", "2"),
+	evalTest("(quotient -17 5) (remainder -17 5) (modulo -17 5)", "-3", "-2", "3"),
+	evalTest("(quotient 17 -5) (remainder 17 -5) (modulo 17 -5)", "-3", "2", "-3"),
+	evalTest("(quotient 17.0 5) (modulo -17 5.0)", "3.0", "3.0"),
+	evalTest("(modulo 100000000000000000001 10)", "1"),
+	evalTest("(floor/ -7 2)", "-4 1"),
+	evalTest("(truncate/ -7 2)", "-3 -1"),
+	evalTest("(call-with-values (lambda () (floor/ 7 2)) list)", "(3 1)"),
+	evalTest("(call-with-values (lambda () (values 1 2 3)) +)", "6"),
+	evalTest("(values 1)", "1"),
+	evalTest("(abs -7) (abs 7/2) (abs -2.5)", "7", "7/2", "2.5"),
+	evalTest("(min 3 1 2) (max 3 1 2)", "1", "3"),
+	evalTest("(max 1 2.0) (min 1 2.0)", "2.0", "1.0"),
+	evalTest("(max 1/2 1/3)", "1/2"),
+	evalTest("(gcd 32 -36) (gcd) (gcd 0 5)", "4", "0", "5"),
+	evalTest("(lcm 32 -36) (lcm) (lcm 0 5)", "288", "1", "0"),
+	evalTest("(gcd 12.0 8)", "4.0"),
+	evalTest("(expt 2 10) (expt 2 100)", "1024", "1267650600228229401496703205376"),
+	evalTest("(expt 2 -2) (expt 2/3 3) (expt 0 0)", "1/4", "8/27", "1"),
+	evalTest("(expt 2.0 3) (expt 4 1/2) (expt 2 0.5)", "8.0", "2.0", "1.4142135623730951"),
+	evalTest("(exact-integer-sqrt 17)", "4 1"),
+	evalTest("(sqrt 16) (sqrt 1/4) (sqrt 2) (sqrt 16.0)", "4", "1/2", "1.4142135623730951", "4.0"),
+	evalTest("(exp 0) (log 1) (log 8 2)", "1.0", "0.0", "3.0"),
+	evalTest("(sin 0) (cos 0) (tan 0)", "0.0", "1.0", "0.0"),
+	evalTest("(asin 0) (acos 1) (atan 0) (atan 1 1)", "0.0", "0.0", "0.0", "0.7853981633974483"),
+	evalTest("(floor 2.5) (ceiling 2.5) (round 2.5) (truncate 2.5)", "2.0", "3.0", "2.0", "2.0"),
+	evalTest("(floor -2.5) (ceiling -2.5) (round -2.5) (truncate -2.5)", "-3.0", "-2.0", "-2.0", "-2.0"),
+	evalTest("(floor 7/2) (ceiling 7/2) (round 7/2) (truncate 7/2)", "3", "4", "4", "3"),
+	evalTest("(floor -7/2) (ceiling -7/2) (round -7/2) (truncate -7/2)", "-4", "-3", "-4", "-3"),
+	evalTest("(round 5/2) (round 7/3) (round 3.7) (floor 5)", "2", "2", "4.0", "5"),
+	evalTest("(number->string 255 16) (number->string -10 2) (number->string 1/3 8)", "\"ff\"", "\"-1010\"", "\"1/3\""),
+	evalTest("(exact 2.5) (exact 2.0) (inexact 1/4) (inexact 3)", "5/2", "2", "0.25", "3.0"),
+	evalTest("(exact? 1/2) (exact? 0.5) (inexact? 0.5)", "#t", "#f", "#t"),
+	evalTest("(integer? 2) (integer? 2.0) (integer? 1/2) (integer? 'a)", "#t", "#t", "#f", "#f"),
+	evalTest("(zero? 0) (zero? 0.0) (zero? 1/2) (zero? +nan.0)", "#t", "#t", "#f", "#f"),
+	evalTest("(positive? 1/2) (positive? -1) (negative? -0.5) (negative? 0)", "#t", "#f", "#t", "#f"),
+	evalTest("(odd? 3) (odd? -3) (even? 0) (even? 4.0) (odd? 100000000000000000001)", "#t", "#t", "#t", "#t", "#t"),
+
 	evalTest("(= 2 1)", "#f"),
 	evalTest("(= (* 100 3) 300)", "#t"),
+	evalTest("(= 1 1.0)", "#t"),
+	evalTest("(= 1/2 0.5)", "#t"),
+	evalTest("(= +nan.0 +nan.0)", "#f"),
+	evalTest("(< 1/3 0.34 1/2)", "#t"),
+	evalTest("(< 99999999999999999999 100000000000000000000)", "#t"),
 
 	evalTest("(< 2 1)", "#f"),
 	evalTest("(< 1 2)", "#t"),
@@ -114,6 +318,7 @@ var interpreterTests = []interpreterTest{
 	evalTest("(list)", "()"),
 	evalTest("(list 1 2 3)", "(1 2 3)"),
 	evalTest("(cdr (list 1 2 3))", "(2 3)"),
+	evalTest("(list 'a (+ 1 2))", "(a 3)"),
 
 	evalTest("(length ())", "0"),
 	evalTest("(length '(1 2))", "2"),
@@ -137,6 +342,11 @@ var interpreterTests = []interpreterTest{
 	evalTest("(symbol->string 'a)", "\"a\""),
 
 	evalTest("(string->number \"1\")", "1"),
+	evalTest("(string->number \"1/2\")", "1/2"),
+	evalTest("(string->number \"#xff\")", "255"),
+	evalTest("(string->number \"abc\")", "#f"),
+	evalTest("(number->string 1/2)", "\"1/2\""),
+	evalTest("(number->string 2.5)", "\"2.5\""),
 	evalTest("(number->string 1)", "\"1\""),
 
 	evalTest("(number? 100", "#t"),
@@ -175,6 +385,9 @@ var interpreterTests = []interpreterTest{
 
 	evalTest("(eq? 1 1)", "#t"),
 	evalTest("(eq? 1 2)", "#f"),
+	evalTest("(eq? 1 1.0)", "#f"),
+	evalTest("(eq? 1/2 2/4)", "#t"),
+	evalTest("(eq? 1.5 1.5)", "#t"),
 	evalTest("(eq? 1 #f)", "#f"),
 	evalTest("(eq? #f #f)", "#t"),
 	evalTest("(eq? () ())", "#t"),
@@ -195,7 +408,10 @@ var interpreterTests = []interpreterTest{
 	evalTest("(equal? 1 #f)", "#f"),
 	evalTest("(equal? #f #f)", "#t"),
 	evalTest("(equal? 'foo 'foo)", "#t"),
-	evalTest("(equal? \"foo\" \"foo\")", "#f"),
+	evalTest("(equal? \"foo\" \"foo\")", "#t"),
+	evalTest("(equal? \"foo\" \"bar\")", "#f"),
+	evalTest("(equal? '(\"a\" (\"b\")) '(\"a\" (\"b\")))", "#t"),
+	evalTest("(eqv? 1 1) (eqv? 1 1.0) (eqv? \"a\" \"a\")", "#t", "#f", "#f"),
 	evalTest("(equal? '(1 1) '(1 2))", "#f"),
 	evalTest("(equal? '(1 2) '(1 2))", "#t"),
 
@@ -267,6 +483,100 @@ var interpreterTests = []interpreterTest{
 	evalTest("(let* ((x 1) (y 2)) (+ x y))", "3"),
 	evalTest("(letrec ((x 1)) x)", "1"),
 	evalTest("(letrec ((x 1) (y 2)) (+ x y))", "3"),
+	evalTest("(define x 1) (let ((x 2) (y x)) y) (let* ((x 2) (y x)) y)", "x", "1", "2"),
+	evalTest("(define x 1) (let ((x 2) (f (lambda () x))) (f)) (let* ((f (lambda () x)) (x 2)) (f))", "x", "1", "1"),
+	evalTest("(let* ((x 1) (x (+ x 1))) x) (let () (define y 3) y)", "2", "3"),
+	evalTest("(letrec ((even? (lambda (n) (if (= n 0) #t (odd? (- n 1))))) (odd? (lambda (n) (if (= n 0) #f (even? (- n 1)))))) (even? 100))", "#t"),
+	evalTest("(letrec ((a (lambda () b)) (b 2)) (a)) (letrec* ((a 1) (b (+ a 1))) (list a b))", "2", "(1 2)"),
+	evalTest("(let loop ((i 0) (acc '())) (if (= i 3) acc (loop (+ i 1) (cons i acc))))", "(2 1 0)"),
+	evalTest("(let ((loop 5)) (let loop ((i loop)) (if (procedure? loop) i 0)))", "5"),
+	evalTest("(let-values (((a b) (values 1 2)) ((c . d) (values 3 4 5)) (e (values 6 7))) (list a b c d e))", "(1 2 3 (4 5) (6 7))"),
+	evalTest("(let ((a 1)) (let-values (((a b) (values 2 a))) (list a b)) (let*-values (((a b) (values 2 a)) ((c) (values a))) (list a b c)))", "(2 1 2)"),
+	evalTest("(let-values (((a b) (values 1 2))) (set! a 10) (+ a b)) (let*-values () 1)", "12", "1"),
+
+	evalTest("(define f (lambda (x y) (if (= x 0) y (f (- x 1) x)))) (f 3 10)", "f", "1"),
+	evalTest("(define f (lambda (x) (cond ((= x 0)) (else (f (- x 1)))))) (f 3)", "f", "#t"),
+	evalTest("(define make-counter (lambda () (let ((n 0)) (lambda () (set! n (+ n 1)) n)))) (define c1 (make-counter)) (define c2 (make-counter)) (c1) (c1) (c2) (c1)", "make-counter", "c1", "c2", "1", "2", "1", "3"),
+	evalTest("(define f (lambda (n) (if (= n 0) 0 (+ (f (- n 1)) n)))) (f 10)", "f", "55"),
+	evalTest("(define f (lambda (n) (let ((m n)) (if (= n 0) 0 (+ (f (- n 1)) m))))) (f 10)", "f", "55"),
+	evalTest("(define adder (lambda (x) (lambda (y) (+ x y)))) (define add1 (adder 1)) (define add2 (adder 2)) (list (add1 10) (add2 10))", "adder", "add1", "add2", "(11 12)"),
+	evalTest("(define procs (do ((i 0 (+ i 1)) (acc '() (cons (lambda () i) acc))) ((= i 3) acc))) (list ((car procs)) ((car (cdr procs))) ((car (cdr (cdr procs)))))", "procs", "(2 1 0)"),
+	evalTest("(define x 10) (define f (lambda () x)) (let ((x 20)) (f))", "x", "f", "10"),
+	evalTest("(let ((x 1)) (let-syntax ((m (syntax-rules () ((_) x)))) (let ((x 2)) (m))))", "1"),
+	evalTest("(do ((i 0 (+ i 1))) ((= i 3) (+ i 1) (+ i 2)))", "5"),
+
+	evalTest("(call/cc (lambda (k) 1))", "1"),
+	evalTest("(call/cc (lambda (k) (k 2) 1))", "2"),
+	evalTest("(+ 1 (call/cc (lambda (k) (+ 10 (k 2)))))", "3"),
+	evalTest("(+ 1 (call/cc (lambda (k) (+ 10 (call/cc (lambda (j) (k 5)))))))", "6"),
+	evalTest("(+ 1 (call/cc (lambda (k) (+ 10 (call/cc (lambda (j) (j 5)))))))", "16"),
+	evalTest("(call-with-current-continuation (lambda (k) (k)))", "#<undef>"),
+	evalTest("(define f (lambda (v) (+ 1 (call/cc (lambda (k) (define g (lambda (x) (if (< x 0) (k x) x))) (vector-map (lambda (x) (* 2 (g x))) v) 0))))) (list (f #(1 -5 3)) (f #(1 2)))", "f", "(-4 1)"),
+	evalTest("(define g (lambda (x) (car x))) (list (guard (e (#t 'caught)) (vector-map (lambda (x) (+ 1 (g x))) #(1))) (vector-map (lambda (x) (+ x 1)) #(1)))", "g", "(caught #(2))"),
+	evalTest("(call/cc procedure?)", "#t"),
+	evalTest("(call/cc (lambda (k) k))", "#<continuation>"),
+	evalTest("(define f (lambda (l) (call/cc (lambda (return) (do ((l l (cdr l))) ((not (pair? l)) #f) (if (< (car l) 0) (return (car l)))))))) (f '(1 -2 3 -4)) (f '(1 2))", "f", "-2", "#f"),
+
+	evalTest("(dynamic-wind (lambda () 1) (lambda () 2) (lambda () 3))", "2"),
+	evalTest("(define r '()) (define add (lambda (x) (set! r (cons x r)))) (dynamic-wind (lambda () (add 'before)) (lambda () (add 'thunk)) (lambda () (add 'after))) r",
+		"r", "add", "(thunk before)", "(after thunk before)"),
+	evalTest("(define r '()) (define add (lambda (x) (set! r (cons x r)))) (call/cc (lambda (k) (dynamic-wind (lambda () (add 'before)) (lambda () (k 'escaped) (add 'thunk)) (lambda () (add 'after))))) r",
+		"r", "add", "escaped", "(after before)"),
+	evalTest("(define r '()) (define add (lambda (x) (set! r (cons x r)))) (call/cc (lambda (k) (dynamic-wind (lambda () (add 1)) (lambda () (dynamic-wind (lambda () (add 2)) (lambda () (k 0)) (lambda () (add 3)))) (lambda () (add 4))))) r",
+		"r", "add", "0", "(4 3 2 1)"),
+
+	evalTest("(guard (e (#t 'caught)) 1)", "1"),
+	evalTest("(guard (e (#t 'caught)) (raise 'oops))", "caught"),
+	evalTest("(guard (e (#t e)) (raise 'oops))", "oops"),
+	evalTest("(guard (e ((symbol? e) 'symbol) ((string? e) 'string)) (raise \"oops\"))", "string"),
+	evalTest("(guard (e ((symbol? e) 'symbol) (else 'else)) (raise 1))", "else"),
+	evalTest("(guard (e (#f 'inner)) (raise 1))", "*** ERROR: unhandled exception: 1"),
+	evalTest("(guard (e (#t (list 'outer e))) (guard (e ((number? e) 'inner)) (raise 'oops)))", "(outer oops)"),
+	evalTest("(guard (e (#t (error-object-message e))) (car '()))", "\"pair required, but got ()\""),
+	evalTest("(guard (e ((error-object? e) (error-object-message e))) (undefined-variable))", "\"Unbound variable: undefined-variable\""),
+	evalTest("(guard (e ((error-object? e) (list (error-object-message e) (error-object-irritants e)))) (error \"bad thing:\" 1 'a))", "(\"bad thing:\" (1 a))"),
+	evalTest("(guard (e (#t (error-object? e))) (raise 'oops))", "#f"),
+	evalTest("(define r '()) (guard (e (#t r)) (dynamic-wind (lambda () (set! r (cons 'before r))) (lambda () (raise 'oops)) (lambda () (set! r (cons 'after r)))))", "r", "(after before)"),
+	evalTest("(with-exception-handler (lambda (e) 'outer) (lambda () (guard (e (#f 'no)) (with-exception-handler (lambda (e) (raise e)) (lambda () (raise-continuable 'oops))))))", "outer"),
+	evalTest("(guard (e (#t (error-object? e))) (memq 1 'a))", "#t"),
+	evalTest("(call/cc (lambda (k) (with-exception-handler (lambda (e) (k (error-object? e))) (lambda () (memq 1 'a)))))", "#t"),
+
+	evalTest("(with-exception-handler (lambda (e) 42) (lambda () (+ (raise-continuable 'oops) 1)))", "43"),
+	evalTest("(with-exception-handler (lambda (e) 1) (lambda () (with-exception-handler (lambda (e) (+ (raise-continuable e) 1)) (lambda () (raise-continuable 'oops)))))", "2"),
+	evalTest("(call/cc (lambda (k) (with-exception-handler (lambda (e) (k (list 'handled e))) (lambda () (raise 'oops)))))", "(handled oops)"),
+	evalTest("(call/cc (lambda (k) (with-exception-handler (lambda (e) (k (error-object-message e))) (lambda () (+ 1 #t)))))", "\"number required, but got #t\""),
+
+	evalTest("(eof-object)", "#<eof>"),
+	evalTest("(eof-object? (eof-object))", "#t"),
+	evalTest("(eof-object? '())", "#f"),
+	evalTest("(current-input-port)", "#<input-port stdin>"),
+	evalTest("(current-output-port)", "#<output-port stdout>"),
+	evalTest("(list (port? (current-input-port)) (input-port? (current-input-port)) (output-port? (current-input-port)))", "(#t #t #f)"),
+	evalTest("(list (port? (current-error-port)) (input-port? (current-error-port)) (output-port? (current-error-port)))", "(#t #f #t)"),
+	evalTest("(port? \"port\")", "#f"),
+
+	evalTest("(define port (open-input-string \"ab\\ncd\")) (read-char port) (read-line port) (read-string 5 port) (read-char port)", "port", "#\\a", "\"b\"", "\"cd\"", "#<eof>"),
+	evalTest("(define port (open-output-string)) (write 'a port) (display \"b\" port) (write \"c\" port) (get-output-string port)", "port", "#<undef>", "#<undef>", "#<undef>", "\"ab\\\"c\\\"\""),
+	evalTest("(define port (open-output-string)) (get-output-string port) (write-char #\\x port) (get-output-string port)", "port", "\"\"", "#<undef>", "\"x\""),
+	evalTest("(with-output-to-string (lambda () (display \"a\") (write #\\b) (newline)))", "\"a#\\\\b\\n\""),
+	evalTest("(call-with-output-string (lambda (port) (display '(1 2) port)))", "\"(1 2)\""),
+	evalTest("(with-output-to-string (lambda () (call/cc (lambda (k) (display 1) (k 2) (display 3))))) (current-output-port)", "\"1\"", "#<output-port stdout>"),
+	evalTest("(list (input-port? (open-input-string \"\")) (output-port? (open-output-string)))", "(#t #t)"),
+
+	evalTest("(read (open-input-string \"(a \\\"b\\\" #\\\\c 1.5 #t #(1 x) #u8(1) (d . e))\"))", "(a \"b\" #\\c 1.5 #t #(1 x) #u8(1) (d . e))"),
+	evalTest("(define port (open-input-string \"foo 42 'x `(a ,b ,@c)\")) (read port) (read port) (read port) (read port) (read port) (read (open-input-string \"'(1 2 (3))\"))",
+		"port", "foo", "42", "'x", "`(a ,b ,@c)", "#<eof>", "'(1 2 (3))"),
+	evalTest("(symbol? (read (open-input-string \"abc\"))) (car (read (open-input-string \"'abc\"))) (read (open-input-string \"#\\\\( #\\\\space\"))", "#t", "quote", "#\\("),
+	evalTest("(define port (open-input-string \"(1 2)rest\")) (read port) (read-char port) (read-line port)", "port", "(1 2)", "#\\r", "\"est\""),
+	evalTest("(read (open-input-string \"; comment\\n  (1 ; inner\\n 2) 3\"))", "(1 2)"),
+	evalTest("(read (open-input-string \"  \")) (read (open-input-string \"\"))", "#<eof>", "#<eof>"),
+	evalTest("(read (open-input-string \"-n\")) (read (open-input-string \"(a 1+ b)\"))", "-n", "(a 1+ b)"),
+	evalTest("(guard (e ((read-error? e) (error-object-message e))) (read (open-input-string \"(1 (2\")))", "\"unexpected end of input: (1 (2\""),
+	evalTest("(guard (e ((read-error? e) (error-object-message e))) (read (open-input-string \")\")))", "\"invalid datum: )\""),
+	evalTest("(guard (e ((read-error? e) 'read-error)) (read (open-input-string \"(1 . 2 3)\")))", "read-error"),
+	evalTest("(guard (e ((read-error? e) (error-object-message e))) (read (open-input-string \"(a #foo)\")))", "\"unknown token: #foo: (a #foo)\""),
+	evalTest("(read (open-input-string \"(a #true #false)\"))", "(a #t #f)"),
+	evalTest("(guard (e ((read-error? e) 'read-error) (else 'other)) (car 1))", "other"),
 
 	evalTest("set!", "#<syntax set!>"),
 	evalTest("if", "#<syntax if>"),
@@ -283,20 +593,55 @@ var interpreterTests = []interpreterTest{
 }
 
 var runtimeErrorTests = []interpreterTest{
+	evalTest("(get-output-string (current-output-port))", "*** ERROR: Compile Error: string output port required, but got #<output-port stdout>"),
 	evalTest("(1)", "*** ERROR: invalid application"),
 	evalTest("hello", "*** ERROR: Unbound variable: hello"),
 	evalTest("((lambda (x) (define y 1) 1) 1) y", "1", "*** ERROR: Unbound variable: y"),
+	evalTest("(define x 'outer) (letrec ((y x) (x 1)) y)", "x", "*** ERROR: Unbound variable: x"),
+	evalTest("(define x 'outer) ((lambda () (set! x 'inner) (define x 1) x))", "x", "*** ERROR: symbol not defined"),
 	evalTest("'1'", "1", "*** ERROR: unterminated quote"),
+	evalTest("`(1 ,", "*** ERROR: unterminated unquote"),
 	evalTest("(last ())", "*** ERROR: pair required: ()"),
 	evalTest("((lambda (x) (set! x 3) x) 2) x", "3", "*** ERROR: Unbound variable: x"),
 	evalTest("(define set! 0) (set! define 0)", "set!", "*** ERROR: invalid application"),
 	evalTest("(define if 0) (if #t 0)", "if", "*** ERROR: invalid application"),
 	evalTest("(define quote 1) '1", "quote", "*** ERROR: invalid application"),
+	evalTest("(define k #f) (call/cc (lambda (c) (set! k c))) (k 1)", "k", "#<continuation>", "*** ERROR: continuation called outside of its dynamic extent"),
+	evalTest("(call/cc 1)", "*** ERROR: Compile Error: procedure required, but got 1"),
+	evalTest("(raise 'oops)", "*** ERROR: unhandled exception: oops"),
+	evalTest("(error \"something wrong:\" 1 \"a\")", "*** ERROR: something wrong: 1 \"a\""),
+	evalTest("(/ 1 0)", "*** ERROR: attempt to calculate a division by zero"),
+	evalTest("(hash-table-ref (make-hash-table) 'a)", "*** ERROR: key not found: a"),
+	evalTest("(vector-ref #(1 2) 2)", "*** ERROR: index out of range: 2"),
+	evalTest("(bytevector-u8-set! (make-bytevector 1) 1 0)", "*** ERROR: index out of range: 1"),
+	evalTest("(string-ref \"abc\" 3)", "*** ERROR: index out of range: 3"),
+	evalTest("(substring \"abc\" 2 1)", "*** ERROR: start index 2 is greater than end index 1"),
+	evalTest("(string-join '() \" \" 'strict-infix)", "*** ERROR: string-join: list must not be empty with strict-infix grammar"),
+	evalTest("\"\\q\"", "*** ERROR: invalid escape sequence in string: \"\\q\""),
+	evalTest("\"\\x41\"", "*** ERROR: invalid escape sequence in string: \"\\x41\""),
+	evalTest("#\\foo", "*** ERROR: invalid character name: #\\foo"),
+	evalTest("'(a #xZZ b)", "*** ERROR: invalid number literal: #xZZ"),
+	evalTest("#e1.5.5", "*** ERROR: invalid number literal: #e1.5.5"),
+	evalTest("(modulo 1 0)", "*** ERROR: attempt to calculate a division by zero"),
+	evalTest("(expt 0 -1)", "*** ERROR: attempt to calculate a division by zero"),
+	evalTest("(exact +inf.0)", "*** ERROR: exact infinity/nan is not supported: +inf.0"),
+	evalTest("(number->string 1.5 2)", "*** ERROR: inexact number can be written only in radix 10: 1.5"),
+	evalTest("(with-exception-handler (lambda (e) 0) (lambda () (raise 'oops)))", "*** ERROR: exception handler returned from non-continuable exception: oops"),
+	evalTest("(guard (e ((string? e) e)) (raise 'oops))", "*** ERROR: unhandled exception: oops"),
+	evalTest("(define-macro (m) (list 1 2)) (m)", "m", "*** ERROR: invalid application"),
 }
 
 var compileErrorTests = []interpreterTest{
 	evalTest("(quote)", "*** ERROR: Compile Error: syntax-error: malformed quote: (quote)"),
+	evalTest(",x", "*** ERROR: Compile Error: syntax-error: unquote appeared outside quasiquote: ,x"),
+	evalTest("(define x '(1)) (unquote-splicing x)", "x", "*** ERROR: Compile Error: syntax-error: unquote-splicing appeared outside quasiquote: ,@x"),
+	evalTest("`,@(list 1)", "*** ERROR: Compile Error: syntax-error: unquote-splicing appeared in invalid context: ,@(list 1)"),
+	evalTest("`(1 ,@2)", "*** ERROR: Compile Error: list required for unquote-splicing, but got 2"),
 	evalTest("(define)", "*** ERROR: Compile Error: syntax-error: malformed define: (define)"),
+	evalTest("(let ((x 1) 2) x)", "*** ERROR: Compile Error: syntax-error: malformed let: (let ((x 1) 2) x)"),
+	evalTest("(let loop)", "*** ERROR: Compile Error: syntax-error: malformed let: (let loop)"),
+	evalTest("(let-values ((a)) a)", "*** ERROR: Compile Error: syntax-error: malformed let-values: (let-values ((a)) a)"),
+	evalTest("(let-values (((a b) (values 1))) a)", "*** ERROR: Compile Error: wrong number of arguments: requires 2, but got 1"),
 
 	evalTest("(-)", "*** ERROR: Compile Error: procedure requires at least 1 argument"),
 	evalTest("(/)", "*** ERROR: Compile Error: procedure requires at least 1 argument"),
@@ -319,6 +664,50 @@ var compileErrorTests = []interpreterTest{
 	evalTest("(symbol->string \"\")", "*** ERROR: Compile Error: symbol required, but got \"\""),
 	evalTest("(string->number 1)", "*** ERROR: Compile Error: string required, but got 1"),
 	evalTest("(number->string \"1\")", "*** ERROR: Compile Error: number required, but got \"1\""),
+	evalTest("(char->integer \"a\")", "*** ERROR: Compile Error: char required, but got \"a\""),
+	evalTest("(char=? #\\a 1)", "*** ERROR: Compile Error: char required, but got 1"),
+	evalTest("(integer->char -1)", "*** ERROR: Compile Error: valid unicode code point required, but got -1"),
+	evalTest("(integer->char 55296)", "*** ERROR: Compile Error: valid unicode code point required, but got 55296"),
+	evalTest("(string-length 'a)", "*** ERROR: Compile Error: string required, but got a"),
+	evalTest("(string-ref \"abc\" -1)", "*** ERROR: Compile Error: index required, but got -1"),
+	evalTest("(string-set! \"abc\" 0 \"x\")", "*** ERROR: Compile Error: char required, but got \"x\""),
+	evalTest("(list->string '(1))", "*** ERROR: Compile Error: char required, but got 1"),
+	evalTest("(string-index \"abc\" 1)", "*** ERROR: Compile Error: char or procedure required, but got 1"),
+	evalTest("(string-join '(\"a\") \" \" 'other)", "*** ERROR: Compile Error: one of infix, strict-infix, prefix and suffix required, but got other"),
+	evalTest("(vector-ref '(1) 0)", "*** ERROR: Compile Error: vector required, but got (1)"),
+	evalTest("(vector-ref #(1) 'a)", "*** ERROR: Compile Error: number required, but got a"),
+	evalTest("(vector-map 1 #(1))", "*** ERROR: Compile Error: procedure required, but got 1"),
+	evalTest("(bytevector 256)", "*** ERROR: Compile Error: byte required, but got 256"),
+	evalTest("#u8(1 a)", "*** ERROR: Compile Error: number required, but got a"),
+	evalTest("(make-hash-table car)", "*** ERROR: Compile Error: one of eq?, eqv?, equal? and string=? required, but got #<subr car>"),
+	evalTest("(hash-table-ref '() 'a)", "*** ERROR: Compile Error: hash-table required, but got ()"),
+	evalTest("(hash-table-set! (make-hash-table) 'a)", "*** ERROR: Compile Error: hash-table-set! requires pairs of key and value"),
+	evalTest("(hash-table-set! (make-hash-table string=?) 'a 1)", "*** ERROR: Compile Error: string required, but got a"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (point-x 1)", "<point>", "*** ERROR: Compile Error: point required, but got 1"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (define-record-type <other> (make-other) other?) (point-x (make-other))", "<point>", "<other>", "*** ERROR: Compile Error: point required, but got #<other>"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (make-point 1)", "<point>", "*** ERROR: Compile Error: wrong number of arguments: requires 2, but got 1"),
+	evalTest("(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (vector-ref (make-point 1 2) 0)", "<point>", "*** ERROR: Compile Error: vector required, but got #<point x: 1 y: 2>"),
+	evalTest("(define-record-type <string> (make-s) s?) (string-length (make-s))", "<string>", "*** ERROR: Compile Error: string required, but got #<string>"),
+	evalTest("(define-record-type <pair> (make-p) p?) (equal? (make-p) '(1)) (eqv? (make-p) '(1))", "<pair>", "#f", "#f"),
+	evalTest("(define-record-type <p> (make-p z) p? (x p-x))", "*** ERROR: Compile Error: syntax-error: unknown field z in define-record-type"),
+	evalTest("(define-record-type <p> (make-p))", "*** ERROR: Compile Error: syntax-error: malformed define-record-type: (define-record-type <p> (make-p))"),
+	evalTest("(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp))))) (swap! 1)", "swap!", "*** ERROR: Compile Error: syntax-error: no matching syntax rule for (swap! 1)"),
+	evalTest("(define-syntax bad (syntax-rules () ((_ x ...) x))) (bad 1)", "bad", "*** ERROR: Compile Error: syntax-error: pattern variable x is used without ellipsis"),
+	evalTest("(define-syntax bad (syntax-rules () ((_ x) (x ...)))) (bad 1)", "bad", "*** ERROR: Compile Error: syntax-error: no pattern variable followed by ellipsis in x"),
+	evalTest("(define-syntax loop (syntax-rules () ((_) (loop)))) (loop)", "loop", "*** ERROR: Compile Error: syntax-error: macro expansion is nested too deeply: (loop)"),
+	evalTest("(define-syntax m (syntax-rules () ((_ x) (list x (m x))))) (m 1)", "m", "*** ERROR: Compile Error: syntax-error: macro expansion is nested too deeply: (m 1)"),
+	evalTest("(define-syntax bad 1)", "*** ERROR: Compile Error: syntax-error: syntax-rules required, but got 1"),
+	evalTest("(define-syntax bad (syntax-rules () (x)))", "*** ERROR: Compile Error: syntax-error: malformed syntax-rules: (syntax-rules () (x))"),
+	evalTest("((lambda (a b . c) c) 1)", "*** ERROR: Compile Error: wrong number of arguments: requires at least 2, but got 1"),
+	evalTest("(define-macro m 1)", "*** ERROR: Compile Error: syntax-error: procedure required for define-macro, but got 1"),
+	evalTest("(define-macro m)", "*** ERROR: Compile Error: syntax-error: malformed define-macro: (define-macro m)"),
+	evalTest("(gensym 1)", "*** ERROR: Compile Error: string required, but got 1"),
+	evalTest("(number->string 1 3)", "*** ERROR: Compile Error: radix must be 2, 8, 10 or 16, but got 3"),
+	evalTest("(number->string 1 2 3)", "*** ERROR: Compile Error: wrong number of arguments: requires at most 2, but got 3"),
+	evalTest("(quotient 1/2 2)", "*** ERROR: Compile Error: integer required, but got 1/2"),
+	evalTest("(odd? 1.5)", "*** ERROR: Compile Error: integer required, but got 1.5"),
+	evalTest("(exact-integer-sqrt -1)", "*** ERROR: Compile Error: exact nonnegative integer required, but got -1"),
+	evalTest("(abs 'a)", "*** ERROR: Compile Error: number required, but got a"),
 
 	evalTest("(car ())", "*** ERROR: Compile Error: pair required, but got ()"),
 	evalTest("(cdr ())", "*** ERROR: Compile Error: pair required, but got ()"),
@@ -338,22 +727,57 @@ var compileErrorTests = []interpreterTest{
 	evalTest("(do ((i 1 1 1)) (#t))", "*** ERROR: Compile Error: bad update expr in do: (do ((i 1 1 1)) (#t))"),
 
 	evalTest("(define 1 1)", "*** ERROR: Compile Error: syntax-error: (define 1 1)"),
+	evalTest("(define-syntax m (syntax-rules () ((_) 'macro))) (define (f m) (m))", "m", "*** ERROR: Compile Error: syntax-error: (define (f m) (m))"),
+}
+
+// Each loop iterates a million times, which overflows Go's stack
+// unless the call in tail position is evaluated in constant space.
+var tailCallTests = []interpreterTest{
+	evalTest("(define loop (lambda (n) (if (= n 0) 'done (loop (- n 1))))) (loop 1000000)", "loop", "done"),
+	evalTest("(define loop (lambda (n acc) (if (= n 0) acc (loop (- n 1) (+ acc 1))))) (loop 1000000 0)", "loop", "1000000"),
+	evalTest("(define loop (lambda (n) (cond ((= n 0) 'done) (else (loop (- n 1)))))) (loop 1000000)", "loop", "done"),
+	evalTest("(define loop (lambda (n) (and #t (or (= n 0) (begin (loop (- n 1))))))) (loop 1000000)", "loop", "#t"),
+	evalTest("(define loop (lambda (n) (let ((m (- n 1))) (let* ((k m)) (letrec ((j k)) (if (< j 0) 'done (loop j))))))) (loop 1000000)", "loop", "done"),
+	evalTest("(define even? (lambda (n) (if (= n 0) #t (odd? (- n 1))))) (define odd? (lambda (n) (if (= n 0) #f (even? (- n 1))))) (even? 1000000)", "even?", "odd?", "#t"),
+	evalTest("(let loop ((n 1000000)) (if (= n 0) 'done (loop (- n 1))))", "done"),
+	evalTest("(let loop ((n 1000000)) (call-with-values (lambda () n) (lambda (m) (if (= m 0) 'done (loop (- m 1))))))", "done"),
 }
 
 func evalTest(source string, results ...string) interpreterTest {
 	return interpreterTest{source: source, results: results}
 }
 
-func runTests(t *testing.T, tests []interpreterTest) {
-	for _, test := range tests {
-		i := NewInterpreter(test.source)
-		evalResults := i.EvalSource(false)
+// Each test runs on the evaluator and the virtual machine.
+var modes = []bool{false, true}
 
-		for i := 0; i < len(test.results); i++ {
-			expect := test.results[i]
-			actual := evalResults[i]
-			if actual != expect {
-				t.Errorf("%s => %s; want %s", test.source, actual, expect)
+func newTestInterpreter(source string, compiled bool) *Interpreter {
+	i := NewInterpreter(source)
+	if compiled {
+		i.SetVM(true)
+	}
+	return i
+}
+
+// Returns the source with the mode, which is printed on failure.
+func testName(source string, compiled bool) string {
+	if compiled {
+		return source + " [vm]"
+	}
+	return source
+}
+
+func runTests(t *testing.T, tests []interpreterTest) {
+	for _, compiled := range modes {
+		for _, test := range tests {
+			i := newTestInterpreter(test.source, compiled)
+			evalResults := i.EvalSource(false)
+
+			for i := 0; i < len(test.results); i++ {
+				expect := test.results[i]
+				actual := evalResults[i]
+				if actual != expect {
+					t.Errorf("%s => %s; want %s", testName(test.source, compiled), actual, expect)
+				}
 			}
 		}
 	}
@@ -365,6 +789,206 @@ func TestInterpreter(t *testing.T) {
 	runTests(t, compileErrorTests)
 }
 
+func TestDisplayString(t *testing.T) {
+	tests := []struct {
+		source string
+		result string
+	}{
+		{"\"a\\\"b\\n\"", "a\"b\n"},
+		{"#\\a", "a"},
+		{"'(\"a\" #\\b (\"c\") 1)", "(a b (c) 1)"},
+		{"(cons \"a\" \"b\")", "(a . b)"},
+		{"'sym", "sym"},
+		{"#(\"a\" #\\b)", "#(a b)"},
+	}
+
+	for _, compiled := range modes {
+		for _, test := range tests {
+			object, err := newTestInterpreter(test.source, compiled).Eval()
+			if err != nil {
+				t.Errorf("%s => %s", testName(test.source, compiled), err)
+			} else if actual := displayString(object); actual != test.result {
+				t.Errorf("%s => %q; want %q", testName(test.source, compiled), actual, test.result)
+			}
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	var syntaxError *SyntaxError
+	var unboundVariableError *UnboundVariableError
+	var arityError *ArityError
+	var typeError *TypeError
+	var runtimeError *RuntimeError
+	var raiseError *RaiseError
+	var readError *ReadError
+
+	tests := []struct {
+		source  string
+		target  interface{}
+		message string
+		object  string
+	}{
+		{"(quote)", &syntaxError, "malformed quote: (quote)", "(quote)"},
+		{"(define 1 1)", &syntaxError, "(define 1 1)", "(define 1 1)"},
+		{"(+ 1 hello)", &unboundVariableError, "Unbound variable: hello", "hello"},
+		{"(car)", &arityError, "wrong number of arguments: requires 1, but got 0", "()"},
+		{"((lambda (x) x))", &arityError, "wrong number of arguments: requires 1, but got 0", "()"},
+		{"(+ 1 #t)", &typeError, "number required, but got #t", "#t"},
+		{"(1)", &typeError, "invalid application", "1"},
+		{"(load \"not-exist.scm\")", &runtimeError, "cannot find \"not-exist.scm\"", ""},
+		{"(error \"oops\" 1 2)", &raiseError, "oops", ""},
+		{"(raise 'oops)", &raiseError, "unhandled exception: oops", "oops"},
+		{"(guard (e ((string? e) e)) (cdr 1))", &typeError, "pair required, but got 1", "1"},
+		{"(read (open-input-string \"#(1\"))", &readError, "unexpected end of input: #(1", ""},
+	}
+
+	for _, compiled := range modes {
+		for _, test := range tests {
+			_, err := newTestInterpreter(test.source, compiled).Eval()
+			if !errors.As(err, test.target) {
+				t.Errorf("%s => %T; want %T", testName(test.source, compiled), err, test.target)
+				continue
+			}
+
+			var schemeError Error
+			if !errors.As(err, &schemeError) {
+				t.Errorf("%s => %T; want scheme.Error", testName(test.source, compiled), err)
+				continue
+			}
+			if schemeError.Message() != test.message {
+				t.Errorf("%s => %s; want %s", testName(test.source, compiled), schemeError.Message(), test.message)
+			}
+			object := ""
+			if schemeError.Object() != nil {
+				object = schemeError.Object().String()
+			}
+			if object != test.object {
+				t.Errorf("%s => %s; want %s", testName(test.source, compiled), object, test.object)
+			}
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{"(define x 1)\n(+ x\n   y)", "test.scm:3:4: Unbound variable: y"},
+		{"(define f (lambda (x) (car x)))\n\n  (f '())", "test.scm:1:23: Compile Error: pair required, but got ()"},
+		{"(if)", "test.scm:1:1: Compile Error: syntax-error: malformed if: (if)"},
+		{"(error \"oops:\" 1)", "test.scm:1:1: oops: 1"},
+		{"(let ((port (open-input-string \"(a)\\n  (b \\\"c\"))) (read port) (read port))", "string:2:3: unexpected end of input: (b \"c"},
+	}
+
+	for _, compiled := range modes {
+		for _, test := range tests {
+			interpreter := newTestInterpreter(test.source, compiled)
+			interpreter.SetFilename("test.scm")
+			_, err := interpreter.Eval()
+			if err == nil || err.Error() != test.message {
+				t.Errorf("%s => %v; want %s", testName(test.source, compiled), err, test.message)
+			}
+		}
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	// Frames of (f x) and (g 2) are replaced by the applications in tail position.
+	source := "(define f (lambda (x) (+ (car x))))\n(define g (lambda (x) (+ 1 (f x))))\n(list (g 2))"
+	expects := []string{"(car x) at test.scm:1:26", "(+ (car x)) at test.scm:1:23", "(+ 1 (f x)) at test.scm:2:23", "(list (g 2)) at test.scm:3:1"}
+
+	for _, compiled := range modes {
+		interpreter := newTestInterpreter(source, compiled)
+		interpreter.SetFilename("test.scm")
+		_, err := interpreter.Eval()
+
+		var schemeError Error
+		if !errors.As(err, &schemeError) {
+			t.Errorf("%s => %v; want scheme.Error", testName(source, compiled), err)
+			continue
+		}
+		frames := schemeError.StackTrace()
+		if len(frames) != len(expects) {
+			t.Errorf("%s => %d frames; want %d", testName(source, compiled), len(frames), len(expects))
+			continue
+		}
+		for i, expect := range expects {
+			if frames[i].String() != expect {
+				t.Errorf("%s: frame %d => %s; want %s", testName(source, compiled), i, frames[i], expect)
+			}
+		}
+		if frames[0].Name() != "car" {
+			t.Errorf("%s: frame 0 => %s; want car", testName(source, compiled), frames[0].Name())
+		}
+	}
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	source := "(define loop (lambda (n) (if (= n 0) (guard (e (#t (error-object-message e))) (car '())) (loop (- n 1))))) (loop 1000) (car '())"
+	expects := []string{"loop", "\"pair required, but got ()\"", "*** ERROR: Compile Error: pair required, but got ()"}
+	var group sync.WaitGroup
+	for _, compiled := range append(modes, modes...) {
+		group.Add(1)
+		go func(compiled bool) {
+			defer group.Done()
+			actuals := newTestInterpreter(source, compiled).EvalSource(false)
+			if !areTheSameStrings(actuals, expects) {
+				t.Errorf("%s => %v; want %v", testName(source, compiled), actuals, expects)
+			}
+		}(compiled)
+	}
+	group.Wait()
+}
+
+func TestTailCall(t *testing.T) {
+	runTests(t, tailCallTests)
+}
+
+func TestPort(t *testing.T) {
+	outputPort, errorPort, inputPort := currentOutputPort, currentErrorPort, currentInputPort
+	defer func() { currentOutputPort, currentErrorPort, currentInputPort = outputPort, errorPort, inputPort }()
+
+	tests := []struct {
+		source  string
+		input   string
+		results []string
+		output  string
+	}{
+		{"(display \"a\") (write \"a\") (newline) (write-char #\\b) (print '(1 \"c\"))", "",
+			[]string{"#<undef>", "#<undef>", "#<undef>", "#<undef>", "#<undef>"}, "a\"a\"\nb(1 c)\n"},
+		{"(write-string \"hello\") (write-string \"hello\" (current-output-port) 1 3) (display 1 (current-error-port))", "",
+			[]string{"#<undef>", "#<undef>", "#<undef>"}, "helloel1"},
+		{"(peek-char) (read-char) (char-ready?) (read-line) (read-string 3) (read-line) (read-line) (read-char) (read-string 2) (read-line) (char-ready?)", "ab\r\nline2\nrest",
+			[]string{"#\\a", "#\\a", "#t", "\"b\"", "\"lin\"", "\"e2\"", "\"rest\"", "#<eof>", "#<eof>", "#<eof>", "#t"}, ""},
+		{"(display 1 (current-input-port))", "",
+			[]string{"*** ERROR: Compile Error: output port required, but got #<input-port test>"}, ""},
+		{"(read-char (current-output-port))", "",
+			[]string{"*** ERROR: Compile Error: input port required, but got #<output-port test>"}, ""},
+	}
+
+	for _, compiled := range modes {
+		for _, test := range tests {
+			output, errors := new(bytes.Buffer), new(bytes.Buffer)
+			currentOutputPort = NewOutputPort("test", output)
+			currentErrorPort = NewOutputPort("error", errors)
+			currentInputPort = NewInputPort("test", strings.NewReader(test.input))
+
+			actuals := newTestInterpreter(test.source, compiled).EvalSource(false)
+			for i, expect := range test.results {
+				if i >= len(actuals) || actuals[i] != expect {
+					t.Errorf("%s => %v; want %s", testName(test.source, compiled), actuals, expect)
+					break
+				}
+			}
+			if output.String()+errors.String() != test.output {
+				t.Errorf("%s => %q; want %q", testName(test.source, compiled), output.String()+errors.String(), test.output)
+			}
+		}
+	}
+}
+
 func TestLoad(t *testing.T) {
 	file, err := ioutil.TempFile(os.TempDir(), "load_test")
 	if err != nil {
@@ -375,15 +999,110 @@ func TestLoad(t *testing.T) {
 	defer os.Remove(file.Name())
 
 	source := fmt.Sprintf("(load \"%s\") x (load invalid)", file.Name())
-	interpreter := NewInterpreter(source)
 	expects := []string{"#t", "3", "*** ERROR: Unbound variable: invalid"}
-	actuals := interpreter.EvalSource(false)
+	for _, compiled := range modes {
+		actuals := newTestInterpreter(source, compiled).EvalSource(false)
+		for i := 0; i < len(actuals); i++ {
+			expect := expects[i]
+			actual := actuals[i]
+			if actual != expect {
+				t.Errorf("%s => %s; want %s", testName(source, compiled), actual, expect)
+			}
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	tests := []struct {
+		source string
+		result string
+	}{
+		{"(file-exists? (path \"a.txt\"))", "#f"},
+		{"(call-with-output-file (path \"a.txt\") (lambda (port) (display \"hello\" port) (newline port) (write 'x port)))", "#<undef>"},
+		{"(file-exists? (path \"a.txt\"))", "#t"},
+		{"(call-with-input-file (path \"a.txt\") read-line)", "\"hello\""},
+		{"(with-input-from-file (path \"a.txt\") (lambda () (read-line) (read-line)))", "\"x\""},
+		{"(with-output-to-file (path \"a.txt\") (lambda () (display \"new\")))", "#<undef>"},
+		{"(define port (open-input-file (path \"a.txt\")))", "port"},
+		{"(read-string 10 port)", "\"new\""},
+		{"(close-port port)", "#<undef>"},
+		{"(guard (e ((error-object? e) 'closed)) (read-char port))", "closed"},
+		{"(define port (open-output-file (path \"b.txt\")))", "port"},
+		{"(write-char #\\b port)", "#<undef>"},
+		{"(close-port port)", "#<undef>"},
+		{"(call-with-input-file (path \"b.txt\") read-char)", "#\\b"},
+		{"(make-directory (path \"sub\"))", "#<undef>"},
+		{"(rename-file (path \"a.txt\") (path \"sub/c.txt\"))", "#<undef>"},
+		{"(directory-list (path \"\"))", "(\"b.txt\" \"sub\")"},
+		{"(directory-list (path \"sub\"))", "(\"c.txt\")"},
+		{"(delete-file (path \"b.txt\"))", "#<undef>"},
+		{"(file-exists? (path \"b.txt\"))", "#f"},
+		{"(guard (e ((error-object? e) 'error)) (open-input-file (path \"none\")))", "error"},
+		{"(guard (e ((error-object? e) 'error)) (delete-file (path \"none\")))", "error"},
+	}
+
+	for _, compiled := range modes {
+		directory, err := ioutil.TempDir(os.TempDir(), "file_test")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(directory)
+
+		source := fmt.Sprintf("(define path (lambda (name) (string-append %q \"/\" name)))", directory)
+		for _, test := range tests {
+			source += " " + test.source
+		}
+		actuals := newTestInterpreter(source, compiled).EvalSource(false)
+		for i, test := range tests {
+			if i+1 >= len(actuals) {
+				t.Errorf("%s => no result; want %s", testName(test.source, compiled), test.result)
+				break
+			} else if actual := actuals[i+1]; actual != test.result {
+				t.Errorf("%s => %s; want %s", testName(test.source, compiled), actual, test.result)
+			}
+		}
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkEval(b, fibSource, false)
+}
+
+func BenchmarkFibVM(b *testing.B) {
+	benchmarkEval(b, fibSource, true)
+}
+
+func BenchmarkDoLoop(b *testing.B) {
+	benchmarkEval(b, doLoopSource, false)
+}
+
+func BenchmarkDoLoopVM(b *testing.B) {
+	benchmarkEval(b, doLoopSource, true)
+}
+
+func BenchmarkVectorMap(b *testing.B) {
+	benchmarkEval(b, vectorMapSource, false)
+}
+
+func BenchmarkVectorMapVM(b *testing.B) {
+	benchmarkEval(b, vectorMapSource, true)
+}
+
+const (
+	fibSource       = "(define fib (lambda (n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))) (fib 25)"
+	doLoopSource    = "(do ((i 0 (+ i 1)) (sum 0 (+ sum i))) ((= i 100000) sum))"
+	vectorMapSource = "(vector-map (lambda (x) (* x x)) (make-vector 100000 3))"
+)
 
-	for i := 0; i < len(actuals); i++ {
-		expect := expects[i]
-		actual := actuals[i]
-		if actual != expect {
-			t.Errorf("%s => %s; want %s", source, actual, expect)
+// The interpreter is created out of the measurement,
+// since it loads the builtin library.
+func benchmarkEval(b *testing.B, source string, compiled bool) {
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		interpreter := newTestInterpreter(source, compiled)
+		b.StartTimer()
+		if _, err := interpreter.Eval(); err != nil {
+			b.Fatal(err)
 		}
 	}
 }
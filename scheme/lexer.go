@@ -10,8 +10,17 @@ import (
 )
 
 // Lexer is a struction for lexical analyzer.
+// It holds a peeked token, so that peeking does not consume the source.
 type Lexer struct {
 	scanner.Scanner
+	peekedToken *token
+}
+
+// A token and its position in source code.
+type token struct {
+	text     string
+	position scanner.Position
+	err      interface{} // error raised while scanning, which is raised again by parser
 }
 
 // EOF defined.
@@ -21,6 +30,10 @@ const (
 	IntToken
 	BooleanToken
 	StringToken
+	NumberToken
+	CharToken
+	VectorToken
+	BytevectorToken
 )
 
 var identifierChars = "a-zA-Z?!*/<=>:$%^&_~"
@@ -31,24 +44,42 @@ var identifierExp = fmt.Sprintf("[%s][%s%s]*", identifierChars, identifierChars,
 func NewLexer(source string) *Lexer {
 	lexer := new(Lexer)
 	lexer.Init(strings.NewReader(source))
-	lexer.Mode &^= scanner.ScanChars
+	lexer.Mode &^= scanner.ScanChars | scanner.ScanStrings | scanner.ScanRawStrings
 	return lexer
 }
 
+// SetFilename sets file name of source code, which is
+// recorded to the position of each token.
+func (l *Lexer) SetFilename(filename string) {
+	l.Filename = filename
+}
+
 // TokenType means Non-destructive scanner.Scan().
 // This method returns next token type or unicode character.
-func (l Lexer) TokenType() rune {
+func (l *Lexer) TokenType() rune {
 	token := l.PeekToken()
 	if l.matchRegexp(token, "^[ ]*$") {
 		return EOF
-	} else if l.matchRegexp(token, fmt.Sprintf("^(%s|\\+|-)$", identifierExp)) {
+	} else if l.matchRegexp(token, fmt.Sprintf("^(%s|\\+|-|\\.\\.\\.)$", identifierExp)) {
 		return IdentifierToken
 	} else if l.matchRegexp(token, "^-?[0-9]+$") {
 		return IntToken
-	} else if l.matchRegexp(token, "^#(f|t)$") {
+	} else if l.matchRegexp(token, "^#(f|t|false|true)$") {
 		return BooleanToken
-	} else if l.matchRegexp(token, "\"[^\"]*\"") {
+	} else if strings.HasPrefix(token, "#\\") {
+		return CharToken
+	} else if token == "#(" {
+		return VectorToken
+	} else if token == "#u8(" {
+		return BytevectorToken
+	} else if strings.HasPrefix(token, "\"") {
 		return StringToken
+	} else if parseNumber(token) != nil || l.matchRegexp(token, "^#[xXoObBdDeEiI]") {
+		// Malformed literal such as #xZZ is rejected by parser.
+		return NumberToken
+	} else if token != "." && l.matchRegexp(token, fmt.Sprintf("^[%s0-9.+-]+$", identifierChars)) {
+		// Tokens which start like a number but are not, such as -name or 1+.
+		return IdentifierToken
 	} else {
 		runes := []rune(token)
 		return runes[0]
@@ -56,15 +87,31 @@ func (l Lexer) TokenType() rune {
 }
 
 // PeekToken means Non-desructive Lexer.NextToken().
-func (l Lexer) PeekToken() string {
-	return l.NextToken()
+func (l *Lexer) PeekToken() string {
+	return l.peekToken().text
+}
+
+// TokenPosition returns the position of next token.
+func (l *Lexer) TokenPosition() scanner.Position {
+	return l.peekToken().position
 }
 
 // NextToken returns next token and moves current token reading
 // position to next token position.
 func (l *Lexer) NextToken() string {
-	defer l.ensureAvailability()
-	return l.nextToken()
+	token := l.peekToken()
+	l.peekedToken = nil
+	return token.text
+}
+
+// Peek returns next character of source code, which is not read as token.
+func (l *Lexer) Peek() rune {
+	if l.peekedToken == nil {
+		return l.Scanner.Peek()
+	} else if l.peekedToken.text == "" {
+		return EOF
+	}
+	return []rune(l.peekedToken.text)[0]
 }
 
 // IndentLevel return position of indent from symbol ( and ).
@@ -94,38 +141,114 @@ func (l *Lexer) AllTokens() []string {
 	return tokens
 }
 
-func (l *Lexer) nextToken() string {
+func (l *Lexer) peekToken() *token {
+	if l.peekedToken == nil {
+		l.peekedToken = l.scanToken()
+	}
+	return l.peekedToken
+}
+
+// An error of scanning is kept in the token instead of panic, so that
+// IndentLevel() counts parentheses of incomplete source in REPL.
+func (l *Lexer) scanToken() (t *token) {
+	t = &token{}
+	defer func() { t.err = recover() }()
+	t.text, t.position = l.nextToken()
+	return
+}
+
+func (l *Lexer) nextToken() (string, scanner.Position) {
 	// text/scanner scans text which starts with "'" in one token.
-	if l.Peek() == '\'' {
+	if l.Scanner.Peek() == '\'' {
+		position := l.Pos()
 		l.Next()
-		return "'"
+		return "'", position
 	}
 
-	l.Scan()
-	if l.TokenText() == "#" {
+	tokenType := l.Scan()
+	position := l.Position
+	if tokenType == scanner.Int || tokenType == scanner.Float || l.isNumberPrefix(l.TokenText(), l.Scanner.Peek()) {
+		// text/scanner scans '1/3' or '#x1F' as splitted tokens.
+		// The text may turn out to be an identifier such as -name or 1+.
+		return l.TokenText() + l.scanRawText(), position
+	} else if l.TokenText() == "," && l.Scanner.Peek() == '@' {
+		l.Next()
+		return ",@", position
+	} else if l.TokenText() == "." && l.Scanner.Peek() == '.' {
+		// Ellipsis is scanned as splitted dots.
+		return "." + l.scanRawText(), position
+	} else if l.TokenText() == "\"" {
+		return l.scanStringText(), position
+	} else if l.TokenText() == "#" && l.Scanner.Peek() == '\\' {
+		// The first character after #\ may be a delimiter, such as #\( or #\space.
+		l.Next()
+		return "#\\" + string(l.Next()) + l.scanRawText(), position
+	} else if l.TokenText() == "#" && l.Scanner.Peek() == '(' {
+		l.Next()
+		return "#(", position
+	} else if l.TokenText() == "#" && l.Scanner.Peek() == 'u' {
+		if l.Next(); l.Next() != '8' || l.Next() != '(' {
+			runtimeError("invalid bytevector literal, which must start with #u8(")
+		}
+		return "#u8(", position
+	} else if l.TokenText() == "#" {
 		// text/scanner scans '#t' as '#' and 't'.
 		l.Scan()
 		switch l.TokenText() {
-		case "t", "f":
-			return fmt.Sprintf("#%s", l.TokenText())
+		case "t", "f", "true", "false":
+			return fmt.Sprintf("#%s", l.TokenText()), position
 		default:
-			runtimeError("Tokens which start from '#' are not implemented except #f, #t")
+			runtimeError("unknown token: #%s", l.TokenText())
 		}
 	} else if l.matchRegexp(l.TokenText(), fmt.Sprintf("^%s$", identifierExp)) {
 		// text/scanner scans some signs as splitted token from alphabet token.
 		text := l.TokenText()
-		for l.isIdentifierChar(l.Peek()) {
+		for l.isIdentifierChar(l.Scanner.Peek()) {
 			l.Scan()
 			text = fmt.Sprintf("%s%s", text, l.TokenText())
 		}
-		return text
-	} else if l.TokenText() == "-" && l.matchRegexp(fmt.Sprintf("%c", l.Peek()), "[0-9]") {
-		text := l.TokenText()
-		l.Scan()
-		text = text + l.TokenText()
-		return text
+		return text, position
+	}
+	return l.TokenText(), position
+}
+
+// Returns true when the text and next character start a number literal,
+// such as -1, +.5, -inf.0 or #x1F.
+func (l *Lexer) isNumberPrefix(text string, next rune) bool {
+	switch text {
+	case "+", "-":
+		return strings.ContainsRune("0123456789.in", next)
+	case "#":
+		return strings.ContainsRune("xXoObBdDeEiI", next)
+	}
+	return false
+}
+
+// Read string literal until the closing double quote.
+// Escape sequences are kept as they are, and decoded by parser.
+func (l *Lexer) scanStringText() string {
+	text := []rune{'"'}
+	for {
+		char := l.Next()
+		if char == scanner.EOF {
+			runtimeError("unterminated string literal")
+		}
+		text = append(text, char)
+		if char == '\\' {
+			text = append(text, l.Next())
+		} else if char == '"' {
+			return string(text)
+		}
 	}
-	return l.TokenText()
+}
+
+// Read characters until a delimiter, without tokenizing.
+func (l *Lexer) scanRawText() string {
+	text := []rune{}
+	for next := l.Scanner.Peek(); next != scanner.EOF && !strings.ContainsRune(" \t\r\n()'\";", next); next = l.Scanner.Peek() {
+		text = append(text, l.Next())
+	}
+	return string(text)
 }
 
 func (l *Lexer) isIdentifierChar(char rune) bool {
@@ -137,12 +260,7 @@ func (l *Lexer) isIdentifierChar(char rune) bool {
 func (l *Lexer) matchRegexp(matchString string, expression string) bool {
 	re, err := regexp.Compile(expression)
 	if err != nil {
-		runtimeError(err.Error())
+		runtimeError("%s", err)
 	}
 	return re.MatchString(matchString)
 }
-
-func (l *Lexer) ensureAvailability() {
-	// Error message will be printed by interpreter.
-	recover()
-}
@@ -20,9 +20,18 @@ var tokenTypeTests = []tokenTypeTest{
 	{"(", '('},
 	{")", ')'},
 	{"'", '\''},
+	{"`", '`'},
+	{",", ','},
+	{",@", ','},
 
 	{"100", IntToken},
 	{"-1", IntToken},
+	{"1/3", NumberToken},
+	{"-3.14", NumberToken},
+	{"1e10", NumberToken},
+	{"#x1F", NumberToken},
+	{"#e1.5", NumberToken},
+	{"+inf.0", NumberToken},
 
 	{"#f", BooleanToken},
 	{"#f", BooleanToken},
@@ -31,8 +40,21 @@ var tokenTypeTests = []tokenTypeTest{
 	{"-", IdentifierToken},
 	{"f2000", IdentifierToken},
 	{"a0?!*/<=>:$%^&_~", IdentifierToken},
+	{"...", IdentifierToken},
+	{"-name", IdentifierToken},
+	{"1+", IdentifierToken},
+	{"-1+", IdentifierToken},
+	{"1/0", IdentifierToken},
+	{"#xZZ", NumberToken},
 
 	{"\"a b\"", StringToken},
+
+	{"#\\a", CharToken},
+	{"#\\(", CharToken},
+	{"#\\space", CharToken},
+
+	{"#(", VectorToken},
+	{"#u8(", BytevectorToken},
 }
 
 var tokenizeTests = []tokenizeTest{
@@ -40,6 +62,13 @@ var tokenizeTests = []tokenizeTest{
 	{"-1", makeTokens("-1")},
 	{"#f", makeTokens("#f")},
 	{"#t", makeTokens("#t")},
+	{"1/3", makeTokens("1/3")},
+	{"-.5", makeTokens("-.5")},
+	{"#x1F", makeTokens("#x1F")},
+	{"'(1/2 #i3)", makeTokens("',(,1/2,#i3,)")},
+	{"(#\\a #\\( #\\))", makeTokens("(,#\\a,#\\(,#\\),)")},
+	{"#(1 #(2))", makeTokens("#(,1,#(,2,),)")},
+	{"'#u8(1 255)", makeTokens("',#u8(,1,255,)")},
 
 	{"(+ 1)", makeTokens("(,+,1,)")},
 	{"(+ 1 (+ 1))", makeTokens("(,+,1,(,+,1,),)")},
@@ -59,10 +88,36 @@ var tokenizeTests = []tokenizeTest{
 	{"'hello", makeTokens("',hello")},
 	{"'(1 2 3)", makeTokens("',(,1,2,3,)")},
 	{"'(1(2 3))", makeTokens("',(,1,(,2,3,),)")},
+	{"`(a ,b ,@c)", []string{"`", "(", "a", ",", "b", ",@", "c", ")"}},
 
 	{"\"a b\"", makeTokens("\"a b\"")},
 
 	{"(set! x 1)", makeTokens("(,set!,x,1,)")},
+	{"(_ x ...)", makeTokens("(,_,x,...,)")},
+	{"(a -name 1+ b)", makeTokens("(,a,-name,1+,b,)")},
+	{"(define -inc 5)", makeTokens("(,define,-inc,5,)")},
+}
+
+func TestTokenPosition(t *testing.T) {
+	l := NewLexer("(define x\n  '(1 -2))")
+	l.SetFilename("test.scm")
+	expects := []string{"test.scm:1:1", "test.scm:1:2", "test.scm:1:9", "test.scm:2:3", "test.scm:2:4", "test.scm:2:5", "test.scm:2:7", "test.scm:2:9", "test.scm:2:10"}
+	for _, expect := range expects {
+		position := l.TokenPosition()
+		token := l.NextToken()
+		if position.String() != expect {
+			t.Errorf("%s => %s; want %s", token, position, expect)
+		}
+	}
+}
+
+func TestLongSource(t *testing.T) {
+	source := strings.Repeat("(define x 1)\n", 1000)
+	l := NewLexer(source)
+	actual := len(l.AllTokens())
+	if actual != 5000 {
+		t.Errorf("%d tokens; want 5000", actual)
+	}
 }
 
 func TestTokenType(t *testing.T) {
@@ -96,6 +151,14 @@ func tokenTypeString(tokenType rune) string {
 		return "IntToken"
 	case StringToken:
 		return "StringToken"
+	case NumberToken:
+		return "NumberToken"
+	case CharToken:
+		return "CharToken"
+	case VectorToken:
+		return "VectorToken"
+	case BytevectorToken:
+		return "BytevectorToken"
 	default:
 		return fmt.Sprintf("%c", tokenType)
 	}
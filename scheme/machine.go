@@ -0,0 +1,202 @@
+// Machine host is the interface of the interpreter for the virtual machine.
+// Compiled code shares environments, call stack and builtin procedures
+// with the evaluator. Procedures which are not compiled, such as builtin
+// functions, and forms which are not compiled are evaluated by the evaluator.
+
+package scheme
+
+import "gosc/vm"
+
+// MachineHost is a struction for vm.Host of the interpreter.
+type machineHost struct{}
+
+var host = &machineHost{}
+
+// Run the code in the environment on the machine of the running context,
+// and returns its result. The call stack is restored when an error or
+// a continuation escapes from the code.
+func execute(code *vm.Code, environment *Environment) Object {
+	depth := len(running.callStack)
+	defer func() { running.callStack = running.callStack[:depth] }()
+	if running.machine == nil {
+		running.machine = vm.NewMachine(host)
+	}
+	return running.machine.Run(code, environment).(Object)
+}
+
+// Returns the frame of parameters bound to the objects.
+func (l *lambda) bind(environment *Environment, objects []vm.Value) *Environment {
+	if l.rest != nil && len(l.parameters) > len(objects) {
+		arityError(valuesToList(objects), "wrong number of arguments: requires at least %d, but got %d", len(l.parameters), len(objects))
+	} else if l.rest == nil && len(l.parameters) != len(objects) {
+		arityError(valuesToList(objects), "wrong number of arguments: requires %d, but got %d", len(l.parameters), len(objects))
+	}
+
+	frame := NewEnvironment(environment, l.form, l.index)
+	if l.laidOut {
+		for index := range l.parameters {
+			frame.values[index] = objects[index].(Object)
+		}
+	} else {
+		for index, identifier := range l.parameters {
+			frame.define(identifier, objects[index].(Object))
+		}
+	}
+	if l.rest != nil {
+		frame.define(l.rest.identifier, valuesToList(objects[len(l.parameters):]))
+	}
+	return frame
+}
+
+// Returns the list of values, which is built from its tail without appending.
+func valuesToList(values []vm.Value) Object {
+	list := NewPair(nil)
+	for index := len(values) - 1; index >= 0; index-- {
+		pair := NewPair(nil)
+		pair.Car, pair.Cdr = values[index].(Object), list
+		list = pair
+	}
+	return list
+}
+
+func (h *machineHost) Load(environment vm.Environment, variable vm.Value) vm.Value {
+	return variable.(*Variable).evalIn(environment.(*Environment))
+}
+
+func (h *machineHost) Store(environment vm.Environment, variable vm.Value, value vm.Value) {
+	environment.(*Environment).set(variable.(*Variable), value.(Object))
+}
+
+func (h *machineHost) Define(environment vm.Environment, variable vm.Value, value vm.Value) {
+	environment.(*Environment).define(variable.(*Variable).identifier, value.(Object))
+}
+
+func (h *machineHost) IsFalse(value vm.Value) bool {
+	boolean, ok := value.(*Boolean)
+	return ok && !boolean.value
+}
+
+// A compiled closure is invoked by the evaluator on the running machine.
+func (h *machineHost) Closure(procedure vm.Value, environment vm.Environment) vm.Value {
+	closure := NewClosure(procedure.(*lambda).form, environment.(*Environment))
+	closure.lambda = procedure.(*lambda)
+	closure.function = func(arguments Object) Object {
+		assertListMinimum(arguments, 0)
+		pair := arguments.(*Pair)
+		values := make([]vm.Value, 0, pair.ListLength())
+		for ; !pair.isNull(); pair = pair.Cdr.(*Pair) {
+			values = append(values, pair.Car.Eval())
+		}
+		return execute(closure.lambda.code, closure.lambda.bind(closure.environment, values))
+	}
+	return closure
+}
+
+func (h *machineHost) Extend(environment vm.Environment, scope vm.Value, values []vm.Value) vm.Environment {
+	layout := scope.(*frameLayout)
+	frame := NewEnvironment(environment.(*Environment), layout.form, layout.index)
+	for index, value := range values {
+		if layout.laidOut {
+			frame.values[index] = value.(Object)
+		} else {
+			frame.define(layout.identifiers[index], value.(Object))
+		}
+	}
+	return frame
+}
+
+func (h *machineHost) Parent(environment vm.Environment) vm.Environment {
+	return environment.(*Environment).parent
+}
+
+func (h *machineHost) Site(site vm.Value, replace bool) {
+	frame := Frame{application: site.(*Application)}
+	if replace {
+		running.callStack[len(running.callStack)-1] = frame
+	} else {
+		running.callStack = append(running.callStack, frame)
+	}
+}
+
+func (h *machineHost) PopSite() {
+	running.callStack = running.callStack[:len(running.callStack)-1]
+}
+
+func (h *machineHost) IsSyntax(procedure vm.Value) bool {
+	switch procedure.(type) {
+	case *Syntax, *Macro:
+		return true
+	}
+	return false
+}
+
+func (h *machineHost) Enter(procedure vm.Value, arguments []vm.Value) (*vm.Code, vm.Environment, bool) {
+	closure, ok := procedure.(*Closure)
+	if !ok || closure.lambda == nil {
+		return nil, nil, false
+	}
+	running.callStack[len(running.callStack)-1].bounder = closure.Bounder()
+	return closure.lambda.code, closure.lambda.bind(closure.environment, arguments), true
+}
+
+// Builtin procedures applied to two fixnums, which are computed without
+// the list of arguments. Their results are same as the builtin procedures.
+func init() {
+	primitives := map[string]func(n, m int) Object{
+		"+": func(n, m int) Object {
+			if sum := n + m; (sum > n) == (m > 0) {
+				return &Number{kind: fixnumKind, value: sum}
+			}
+			return NewNumber(n).add(NewNumber(m))
+		},
+		"-": func(n, m int) Object {
+			if difference := n - m; (difference < n) == (m > 0) {
+				return &Number{kind: fixnumKind, value: difference}
+			}
+			return NewNumber(n).subtract(NewNumber(m))
+		},
+		"=":  func(n, m int) Object { return &Boolean{value: n == m} },
+		"<":  func(n, m int) Object { return &Boolean{value: n < m} },
+		"<=": func(n, m int) Object { return &Boolean{value: n <= m} },
+		">":  func(n, m int) Object { return &Boolean{value: n > m} },
+		">=": func(n, m int) Object { return &Boolean{value: n >= m} },
+	}
+	for name, primitive := range primitives {
+		builtinProcedure[name].(*Subroutine).fixnumPrimitive = primitive
+	}
+}
+
+func isFixnum(value vm.Value) bool {
+	number, ok := value.(*Number)
+	return ok && number.kind == fixnumKind
+}
+
+// The procedure is applied in the environment, because some builtin
+// procedures such as load refer the current environment.
+func (h *machineHost) Apply(procedure vm.Value, arguments []vm.Value, environment vm.Environment, deferrable bool) vm.Value {
+	object := procedure.(Object)
+	if subroutine, ok := object.(*Subroutine); ok && subroutine.fixnumPrimitive != nil && len(arguments) == 2 {
+		if isFixnum(arguments[0]) && isFixnum(arguments[1]) {
+			return subroutine.fixnumPrimitive(arguments[0].(*Number).value, arguments[1].(*Number).value)
+		}
+	}
+	running.callStack[len(running.callStack)-1].bounder = object.Bounder()
+	invoker, ok := object.(Invoker)
+	if !ok {
+		return raise(&TypeError{newErrorBase("", object, "invalid application")}, false)
+	}
+
+	list := valuesToList(arguments)
+	result := environment.(*Environment).call(func() Object { return invoker.Invoke(list) })
+	if deferrable {
+		return result
+	}
+	return trampoline(result)
+}
+
+func (h *machineHost) Eval(expression vm.Value, environment vm.Environment, deferrable bool) vm.Value {
+	if deferrable {
+		return environment.(*Environment).call(func() Object { return evalTail(expression.(Object)) })
+	}
+	return trampoline(environment.(*Environment).eval(expression.(Object)))
+}
@@ -0,0 +1,552 @@
+// Macro is a type for syntax transformer, which is defined by
+// define-syntax, let-syntax or letrec-syntax with syntax-rules.
+// A macro use is transcribed by the first rule whose pattern matches it.
+//
+// Macro is hygienic by renaming: each identifier introduced by a template
+// is renamed to a fresh identifier, such as tmp#1, which cannot be written
+// in source code. So the binding introduced by the template does not
+// capture variables of the macro use. When the renamed identifier is not
+// bound in the expansion, it refers to the original identifier in the
+// environment where the macro is defined.
+
+package scheme
+
+import (
+	"fmt"
+	"text/scanner"
+)
+
+// The number of identifiers renamed by macro expansion,
+// which makes renamed identifiers unique.
+var renameCount = 0
+
+// Macro is a struction for syntax-rules transformer.
+// Macro defined by define-macro has the transformer procedure instead of rules.
+type Macro struct {
+	ObjectBase
+	ellipsis    string
+	literals    []string
+	rules       []syntaxRule
+	transformer Object
+}
+
+// A pair of pattern and template of syntax-rules.
+type syntaxRule struct {
+	pattern  Object
+	template Object
+}
+
+// A form matched with a pattern variable. A pattern variable followed by
+// ellipsis has the sequence of matched forms, which nests by its depth.
+type matched struct {
+	form     Object
+	sequence []*matched
+	depth    int
+}
+
+// Eval is macro's eval IF.
+func (m *Macro) Eval() Object {
+	return m
+}
+
+func (m *Macro) String() string {
+	return fmt.Sprintf("#<macro %s>", m.Bounder())
+}
+
+// Invoke expands the macro use which is not expanded before evaluation,
+// such as the use of a macro which is defined after the use.
+func (m *Macro) Invoke(arguments Object) Object {
+	expression := expand(m.transform(arguments.Parent()))
+	analyze(expression, running.environment)
+	return evalTail(expression)
+}
+
+// Transcribe the macro use by the first matched rule, or by the transformer.
+// The returned form is placed where the macro use is.
+func (m *Macro) transform(form Object) Object {
+	expansion := m.transcribe(form)
+	if expansion.isApplication() {
+		expansion.setPosition(form.Position())
+	}
+	if expansion != Null && !expansion.isSymbol() {
+		expansion.setParent(form.Parent())
+	}
+	return expansion
+}
+
+func (m *Macro) transcribe(form Object) Object {
+	forms, ok := formElements(form)
+	if !ok {
+		syntaxError(form, "proper list required for macro use: %s", form)
+	}
+
+	// Transformer of define-macro takes forms as data, and returns datum.
+	if m.transformer != nil {
+		data := []Object{}
+		for _, form := range forms[1:] {
+			data = append(data, formToDatum(form))
+		}
+		return datumToForm(applyProcedure(m.transformer, data...))
+	}
+
+	for _, rule := range m.rules {
+		patterns, _ := formElements(rule.pattern)
+		bindings := map[string]*matched{}
+		if m.matchElements(patterns[1:], forms[1:], bindings) {
+			t := &transcriber{ellipsis: m.ellipsis, bindings: bindings, renames: map[string]string{}}
+			return t.transcribe(rule.template)
+		}
+	}
+	syntaxError(form, "no matching syntax rule for %s", form)
+	return nil
+}
+
+func (m *Macro) isEllipsis(object Object) bool {
+	name, ok := keywordName(object)
+	return ok && name == m.ellipsis
+}
+
+func (m *Macro) isLiteral(object Object) bool {
+	name, _ := keywordName(object)
+	for _, literal := range m.literals {
+		if literal == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Macro) match(pattern Object, form Object, bindings map[string]*matched) bool {
+	if identifier, ok := identifierName(pattern); ok {
+		if m.isLiteral(pattern) {
+			name, ok := keywordName(form)
+			literal, _ := keywordName(pattern)
+			return ok && name == literal
+		} else if name, _ := keywordName(pattern); name != "_" {
+			bindings[identifier] = &matched{form: form}
+		}
+		return true
+	}
+
+	if patterns, ok := formElements(pattern); ok {
+		forms, ok := formElements(form)
+		return ok && m.matchElements(patterns, forms, bindings)
+	} else if vector, ok := pattern.(*Vector); ok {
+		formVector, ok := form.(*Vector)
+		return ok && m.matchElements(vector.elements, formVector.elements, bindings)
+	}
+	_, isForm := formElements(form)
+	return !isForm && areEqual(pattern, form)
+}
+
+// Match forms with patterns, where a pattern followed by ellipsis matches
+// zero or more forms, and patterns after the ellipsis match the last forms.
+func (m *Macro) matchElements(patterns []Object, forms []Object, bindings map[string]*matched) bool {
+	index := -1
+	for i := 0; i+1 < len(patterns); i++ {
+		if m.isEllipsis(patterns[i+1]) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if len(patterns) != len(forms) {
+			return false
+		}
+		for i := range patterns {
+			if !m.match(patterns[i], forms[i], bindings) {
+				return false
+			}
+		}
+		return true
+	}
+
+	rest := patterns[index+2:]
+	if len(forms) < index+len(rest) {
+		return false
+	}
+	for i := 0; i < index; i++ {
+		if !m.match(patterns[i], forms[i], bindings) {
+			return false
+		}
+	}
+	for i, pattern := range rest {
+		if !m.match(pattern, forms[len(forms)-len(rest)+i], bindings) {
+			return false
+		}
+	}
+
+	repeated := []map[string]*matched{}
+	for _, form := range forms[index : len(forms)-len(rest)] {
+		repeatedBindings := map[string]*matched{}
+		if !m.match(patterns[index], form, repeatedBindings) {
+			return false
+		}
+		repeated = append(repeated, repeatedBindings)
+	}
+	for identifier, depth := range m.patternVariables(patterns[index], 0) {
+		sequence := []*matched{}
+		for _, repeatedBindings := range repeated {
+			sequence = append(sequence, repeatedBindings[identifier])
+		}
+		bindings[identifier] = &matched{sequence: sequence, depth: depth + 1}
+	}
+	return true
+}
+
+// Returns pattern variables in the pattern with the number of
+// ellipses which follow them in the pattern.
+func (m *Macro) patternVariables(pattern Object, depth int) map[string]int {
+	variables := map[string]int{}
+	if identifier, ok := identifierName(pattern); ok {
+		if name, _ := keywordName(pattern); name != "_" && !m.isEllipsis(pattern) && !m.isLiteral(pattern) {
+			variables[identifier] = depth
+		}
+		return variables
+	}
+
+	elements, ok := formElements(pattern)
+	if vector, isVector := pattern.(*Vector); isVector {
+		elements, ok = vector.elements, true
+	}
+	for i := 0; ok && i < len(elements); i++ {
+		elementDepth := depth
+		if i+1 < len(elements) && m.isEllipsis(elements[i+1]) {
+			elementDepth++
+		}
+		for identifier, variableDepth := range m.patternVariables(elements[i], elementDepth) {
+			variables[identifier] = variableDepth
+		}
+	}
+	return variables
+}
+
+// Transcriber instantiates a template with matched forms.
+// Identifiers introduced by the template are renamed, and the same
+// identifier is renamed to the same one in an expansion.
+type transcriber struct {
+	ellipsis string
+	bindings map[string]*matched
+	renames  map[string]string
+}
+
+func (t *transcriber) isEllipsis(object Object) bool {
+	name, ok := keywordName(object)
+	return ok && t.ellipsis != "" && name == t.ellipsis
+}
+
+func (t *transcriber) transcribe(template Object) Object {
+	switch template.(type) {
+	case *Variable:
+		variable := template.(*Variable)
+		if binding, ok := t.bindings[variable.identifier]; ok {
+			if binding.depth > 0 {
+				syntaxError(template, "pattern variable %s is used without ellipsis", variable)
+			}
+			return copyForm(binding.form)
+		}
+		return t.rename(variable)
+	case *Application:
+		elements, _ := formElements(template)
+		if len(elements) == 2 && t.isEllipsis(elements[0]) {
+			// (... template) escapes ellipsis in the template.
+			escaped := &transcriber{ellipsis: "", bindings: t.bindings, renames: t.renames}
+			return escaped.transcribe(elements[1])
+		}
+		return newForm(t.transcribeElements(elements), template.Position())
+	case *Vector:
+		elements := []Object{}
+		for _, element := range t.transcribeElements(template.(*Vector).elements) {
+			elements = append(elements, formToDatum(element))
+		}
+		return NewVector(elements)
+	case *Symbol:
+		// Identifier in vector template is parsed as symbol.
+		if binding, ok := t.bindings[template.String()]; ok && binding.depth == 0 {
+			return formToDatum(binding.form)
+		}
+		return template
+	default:
+		return template
+	}
+}
+
+func (t *transcriber) transcribeElements(templates []Object) []Object {
+	objects := []Object{}
+	for i := 0; i < len(templates); i++ {
+		template := templates[i]
+		depth := 0
+		for i+1 < len(templates) && t.isEllipsis(templates[i+1]) {
+			depth++
+			i++
+		}
+		if depth == 0 {
+			objects = append(objects, t.transcribe(template))
+		} else {
+			objects = append(objects, t.transcribeSequence(template, depth)...)
+		}
+	}
+	return objects
+}
+
+// Transcribe the template followed by ellipses for each matched form.
+// Pattern variables whose depth is deeper than the one in the template
+// are iterated, and the others are the same in each iteration.
+func (t *transcriber) transcribeSequence(template Object, depth int) []Object {
+	identifiers := []string{}
+	length := -1
+	for identifier, templateDepth := range t.templateVariables(template, 0) {
+		binding, ok := t.bindings[identifier]
+		if !ok || binding.depth <= templateDepth {
+			continue
+		}
+		if length >= 0 && length != len(binding.sequence) {
+			syntaxError(template, "pattern variables followed by ellipsis have different lengths in %s", template)
+		}
+		length = len(binding.sequence)
+		identifiers = append(identifiers, identifier)
+	}
+	if length < 0 {
+		syntaxError(template, "no pattern variable followed by ellipsis in %s", template)
+	}
+
+	objects := []Object{}
+	for index := 0; index < length; index++ {
+		bindings := map[string]*matched{}
+		for identifier, binding := range t.bindings {
+			bindings[identifier] = binding
+		}
+		for _, identifier := range identifiers {
+			bindings[identifier] = t.bindings[identifier].sequence[index]
+		}
+
+		iteration := &transcriber{ellipsis: t.ellipsis, bindings: bindings, renames: t.renames}
+		if depth > 1 {
+			objects = append(objects, iteration.transcribeSequence(template, depth-1)...)
+		} else {
+			objects = append(objects, iteration.transcribe(template))
+		}
+	}
+	return objects
+}
+
+// Returns identifiers in the template with the number of
+// ellipses which follow them in the template.
+func (t *transcriber) templateVariables(template Object, depth int) map[string]int {
+	variables := map[string]int{}
+	if identifier, ok := identifierName(template); ok {
+		variables[identifier] = depth
+		return variables
+	}
+
+	elements, ok := formElements(template)
+	if vector, isVector := template.(*Vector); isVector {
+		elements, ok = vector.elements, true
+	}
+	if ok && len(elements) == 2 && t.isEllipsis(elements[0]) {
+		escaped := &transcriber{ellipsis: ""}
+		return escaped.templateVariables(elements[1], depth)
+	}
+	for i := 0; ok && i < len(elements); i++ {
+		elementDepth := depth
+		for j := i + 1; j < len(elements) && t.isEllipsis(elements[j]); j++ {
+			elementDepth++
+		}
+		for identifier, variableDepth := range t.templateVariables(elements[i], elementDepth) {
+			if depth, ok := variables[identifier]; !ok || variableDepth > depth {
+				variables[identifier] = variableDepth
+			}
+		}
+	}
+	return variables
+}
+
+// Rename the identifier introduced by the template.
+func (t *transcriber) rename(variable *Variable) Object {
+	identifier, ok := t.renames[variable.identifier]
+	if !ok {
+		renameCount++
+		identifier = fmt.Sprintf("%s#%d", variable, renameCount)
+		t.renames[variable.identifier] = identifier
+	}
+	renamed := NewVariable(identifier, nil)
+	renamed.original = variable
+	renamed.setPosition(variable.Position())
+	return renamed
+}
+
+// Returns elements of the form in AST, which is an application or null.
+func formElements(object Object) ([]Object, bool) {
+	switch object.(type) {
+	case *Application:
+		application := object.(*Application)
+		if !application.arguments.isList() {
+			return nil, false
+		}
+		return append([]Object{application.procedure}, application.arguments.(*Pair).Elements()...), true
+	case *Pair:
+		if object.isNull() {
+			return []Object{}, true
+		}
+	}
+	return nil, false
+}
+
+// Make an application in AST from the elements.
+func newForm(elements []Object, position *scanner.Position) Object {
+	if len(elements) == 0 {
+		return Null
+	}
+	application := NewApplication(nil)
+	application.setPosition(position)
+	application.procedure = elements[0]
+	application.arguments = NewList(application, elements[1:]...)
+	for _, element := range elements {
+		if element != Null && !element.isSymbol() {
+			element.setParent(application)
+		}
+	}
+	return application
+}
+
+// Copy the form in AST, because a form cannot be shared in AST
+// where each object has its parent.
+func copyForm(object Object) Object {
+	switch object.(type) {
+	case *Application:
+		elements, _ := formElements(object)
+		copied := []Object{}
+		for _, element := range elements {
+			copied = append(copied, copyForm(element))
+		}
+		return newForm(copied, object.Position())
+	case *Variable:
+		variable := NewVariable(object.(*Variable).identifier, nil)
+		variable.original = object.(*Variable).original
+		variable.setPosition(object.Position())
+		return variable
+	default:
+		return object
+	}
+}
+
+// Convert the form in AST to the datum, such as an element of vector
+// and an argument of the transformer of define-macro.
+func formToDatum(object Object) Object {
+	switch object.(type) {
+	case *Application:
+		pair := NewPair(nil)
+		pair.Car = formToDatum(object.(*Application).procedure)
+		pair.Cdr = formToDatum(object.(*Application).arguments)
+		return pair
+	case *Pair:
+		if object.isNull() {
+			return Null
+		}
+		pair := NewPair(nil)
+		pair.Car = formToDatum(object.(*Pair).Car)
+		pair.Cdr = formToDatum(object.(*Pair).Cdr)
+		return pair
+	case *Variable:
+		return NewSymbol(object.String())
+	default:
+		return object
+	}
+}
+
+// Convert the datum to the form in AST, where lists are converted to
+// applications and symbols are converted to variables.
+// Positions of lists read from source code are kept in the form.
+func datumToForm(object Object) Object {
+	return datumToFormAt(object, nil)
+}
+
+// Convert the datum at the position in source code, which is given for
+// symbols because they do not have position.
+func datumToFormAt(object Object, position *scanner.Position) Object {
+	switch object.(type) {
+	case *Pair:
+		if object.isNull() {
+			return Null
+		}
+		pair := object.(*Pair)
+		application := NewApplication(nil)
+		application.setPosition(pair.Position())
+		application.datum = pair
+		application.procedure = datumToFormAt(pair.Car, pair.carPosition)
+		application.arguments = datumListToForm(pair.Cdr, application)
+		if application.procedure != Null && !application.procedure.isSymbol() {
+			application.procedure.setParent(application)
+		}
+		return application
+	case *Symbol:
+		if object == undef {
+			return object
+		}
+		variable := NewVariable(object.String(), nil)
+		variable.setPosition(position)
+		return variable
+	default:
+		return object
+	}
+}
+
+// Convert the rest of list, which may be terminated by non-list object.
+// The rest (unquote x) is converted to the application in the last cdr,
+// because `(a . ,x) is read as (quasiquote (a unquote x)).
+func datumListToForm(object Object, parent Object) Object {
+	if !object.isPair() || isUnquoteDatum(object) {
+		if object.isNull() {
+			return NewPair(parent)
+		}
+		form := datumToForm(object)
+		if !form.isSymbol() {
+			form.setParent(parent)
+		}
+		return form
+	}
+
+	pair := NewPair(parent)
+	pair.Car = datumToFormAt(object.(*Pair).Car, object.(*Pair).carPosition)
+	if pair.Car != Null && !pair.Car.isSymbol() {
+		pair.Car.setParent(pair)
+	}
+	pair.Cdr = datumListToForm(object.(*Pair).Cdr, pair)
+	return pair
+}
+
+// Returns true when the datum is the list (unquote x).
+func isUnquoteDatum(object Object) bool {
+	pair, ok := object.(*Pair)
+	if !ok || pair.Car != NewSymbol("unquote") || !pair.Cdr.isPair() {
+		return false
+	}
+	return pair.Cdr.(*Pair).Cdr.isNull()
+}
+
+// Returns the identifier of variable in AST or symbol in vector.
+func identifierName(object Object) (string, bool) {
+	switch object.(type) {
+	case *Variable:
+		return object.(*Variable).identifier, true
+	case *Symbol:
+		return object.String(), true
+	}
+	return "", false
+}
+
+// Returns the identifier written in source code, which is not renamed.
+// Keywords such as ellipsis, underscore and literals are compared by it.
+func keywordName(object Object) (string, bool) {
+	switch object.(type) {
+	case *Variable, *Symbol:
+		return object.String(), true
+	}
+	return "", false
+}
+
+// Returns true when the object is the identifier written as the name.
+func isKeyword(object Object, name string) bool {
+	keyword, ok := keywordName(object)
+	return ok && keyword == name
+}
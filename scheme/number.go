@@ -1,38 +1,182 @@
 // Number is a scheme number object, which is expressed by number literal.
+// Number has one of the following representations:
+//   fixnum: exact integer which fits in Go's int
+//   bignum: exact integer which overflows int, by math/big
+//   ratnum: exact rational number which is not integer, by math/big
+//   flonum: inexact real number by float64
+// Results of arithmetic are normalized to the simplest representation,
+// and an inexact operand makes the result inexact.
 
 package scheme
 
 import (
+	"math"
+	"math/big"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
+type numberKind int
+
+type roundingMode int
+
+const (
+	fixnumKind numberKind = iota
+	bignumKind
+	ratnumKind
+	flonumKind
+)
+
+const (
+	roundFloor roundingMode = iota
+	roundCeiling
+	roundTruncate
+	roundRound
+)
+
+const minInt = -1 << (strconv.IntSize - 1)
+
+var decimalExp = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
 // Number is a struction for using number type.
 type Number struct {
 	ObjectBase
-	value int
+	kind   numberKind
+	value  int
+	bignum *big.Int
+	ratnum *big.Rat
+	flonum float64
 }
 
 // NewNumber is a struction for definition a new number.
+// A string argument is parsed as number literal.
 func NewNumber(argument interface{}, options ...Object) *Number {
-	var value int
-	var err error
+	var number *Number
 
 	switch argument.(type) {
 	case int:
-		value = argument.(int)
+		number = &Number{kind: fixnumKind, value: argument.(int)}
+	case *big.Int:
+		number = newBignum(argument.(*big.Int))
+	case *big.Rat:
+		number = newRatnum(argument.(*big.Rat))
+	case float64:
+		number = &Number{kind: flonumKind, flonum: argument.(float64)}
 	case string:
-		value, err = strconv.Atoi(argument.(string))
-		if err != nil {
-			runtimeError("String conversion %s to integer failed", argument.(string))
+		number = parseNumber(argument.(string))
+		if number == nil {
+			runtimeError("String conversion %s to number failed", argument.(string))
 		}
 	default:
 		runtimeError("Unexpected argument type for NewNumber()")
 	}
 
 	if len(options) > 0 {
-		return &Number{ObjectBase: ObjectBase{parent: options[0]}, value: value}
+		number.parent = options[0]
+	}
+	return number
+}
+
+// Normalize to fixnum when the integer fits in int.
+func newBignum(value *big.Int) *Number {
+	if value.IsInt64() && int64(int(value.Int64())) == value.Int64() {
+		return &Number{kind: fixnumKind, value: int(value.Int64())}
+	}
+	return &Number{kind: bignumKind, bignum: value}
+}
+
+// Normalize to integer when the denominator is 1.
+func newRatnum(value *big.Rat) *Number {
+	if value.IsInt() {
+		return newBignum(new(big.Int).Set(value.Num()))
+	}
+	return &Number{kind: ratnumKind, ratnum: value}
+}
+
+// Parse number literal, such as 1, -1/3, 3.14, 1e10, #x1F, #e1.5, +inf.0.
+// Returns nil when the text is not a number.
+func parseNumber(text string) *Number {
+	radix := 10
+	exactness := byte(0)
+	for len(text) >= 2 && text[0] == '#' {
+		switch text[1] {
+		case 'x', 'X':
+			radix = 16
+		case 'o', 'O':
+			radix = 8
+		case 'b', 'B':
+			radix = 2
+		case 'd', 'D':
+			radix = 10
+		case 'e', 'E', 'i', 'I':
+			if exactness != 0 {
+				return nil
+			}
+			exactness = text[1] | 0x20
+		default:
+			return nil
+		}
+		text = text[2:]
+	}
+
+	// Exact decimal is parsed without rounding, so #e0.1 is 1/10.
+	if exactness == 'e' && radix == 10 && decimalExp.MatchString(text) {
+		if value, ok := new(big.Rat).SetString(text); ok {
+			return newRatnum(value)
+		}
+	}
+
+	number := parseReal(text, radix)
+	if number == nil {
+		return nil
+	}
+	switch exactness {
+	case 'e':
+		return number.toExact()
+	case 'i':
+		return number.toInexact()
+	default:
+		return number
 	}
-	return &Number{value: value}
+}
+
+func parseReal(text string, radix int) *Number {
+	switch text {
+	case "+inf.0":
+		return NewNumber(math.Inf(1))
+	case "-inf.0":
+		return NewNumber(math.Inf(-1))
+	case "+nan.0", "-nan.0":
+		return NewNumber(math.NaN())
+	case "":
+		return nil
+	}
+
+	if strings.Contains(text, "/") {
+		fraction := strings.SplitN(text, "/", 2)
+		numerator, ok := new(big.Int).SetString(fraction[0], radix)
+		if !ok {
+			return nil
+		}
+		denominator, ok := new(big.Int).SetString(fraction[1], radix)
+		if !ok || denominator.Sign() == 0 || strings.ContainsAny(fraction[1], "+-") {
+			return nil
+		}
+		return newRatnum(new(big.Rat).SetFrac(numerator, denominator))
+	}
+
+	if integer, ok := new(big.Int).SetString(text, radix); ok {
+		return newBignum(integer)
+	}
+
+	if radix == 10 && decimalExp.MatchString(text) {
+		value, err := strconv.ParseFloat(text, 64)
+		if err == nil || math.IsInf(value, 0) {
+			return NewNumber(value)
+		}
+	}
+	return nil
 }
 
 // Eval is number's eval IF.
@@ -41,9 +185,395 @@ func (n *Number) Eval() Object {
 }
 
 func (n *Number) String() string {
-	return strconv.Itoa(n.value)
+	return n.text(10)
+}
+
+// Returns the number as text in the radix.
+// Only exact number can be written in radix other than 10.
+func (n *Number) text(radix int) string {
+	switch n.kind {
+	case fixnumKind:
+		return strconv.FormatInt(int64(n.value), radix)
+	case bignumKind:
+		return n.bignum.Text(radix)
+	case ratnumKind:
+		return n.ratnum.Num().Text(radix) + "/" + n.ratnum.Denom().Text(radix)
+	default:
+		if radix != 10 {
+			runtimeError("inexact number can be written only in radix 10: %s", n)
+		}
+		return formatFlonum(n.flonum)
+	}
+}
+
+// Format float as scheme literal, such as 1.0, 0.5, 1.0e21, 1.0e-8, +inf.0.
+func formatFlonum(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+inf.0"
+	case math.IsInf(value, -1):
+		return "-inf.0"
+	case math.IsNaN(value):
+		return "+nan.0"
+	}
+
+	format := byte('f')
+	if abs := math.Abs(value); abs != 0 && (abs < 1e-7 || abs >= 1e21) {
+		format = 'e'
+	}
+	text := strconv.FormatFloat(value, format, -1, 64)
+	mantissa, exponent := text, ""
+	if index := strings.IndexByte(text, 'e'); index >= 0 {
+		mantissa, exponent = text[:index], text[index+1:]
+	}
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if exponent == "" {
+		return mantissa
+	}
+	sign := strings.TrimPrefix(exponent[:1], "+")
+	return mantissa + "e" + sign + strings.TrimLeft(exponent[1:], "0")
 }
 
 func (n *Number) isNumber() bool {
 	return true
 }
+
+func (n *Number) isExact() bool {
+	return n.kind != flonumKind
+}
+
+func (n *Number) isInteger() bool {
+	switch n.kind {
+	case fixnumKind, bignumKind:
+		return true
+	case ratnumKind:
+		return false
+	default:
+		return !math.IsInf(n.flonum, 0) && n.flonum == math.Trunc(n.flonum)
+	}
+}
+
+// Returns exact integer as big.Int.
+func (n *Number) bigInt() *big.Int {
+	if n.kind == fixnumKind {
+		return big.NewInt(int64(n.value))
+	}
+	return n.bignum
+}
+
+// Returns exact number as big.Rat.
+func (n *Number) bigRat() *big.Rat {
+	switch n.kind {
+	case fixnumKind:
+		return new(big.Rat).SetInt64(int64(n.value))
+	case bignumKind:
+		return new(big.Rat).SetInt(n.bignum)
+	default:
+		return n.ratnum
+	}
+}
+
+func (n *Number) float() float64 {
+	switch n.kind {
+	case fixnumKind:
+		return float64(n.value)
+	case flonumKind:
+		return n.flonum
+	default:
+		value, _ := n.bigRat().Float64()
+		return value
+	}
+}
+
+func (n *Number) toExact() *Number {
+	if n.isExact() {
+		return n
+	}
+	if math.IsInf(n.flonum, 0) || math.IsNaN(n.flonum) {
+		runtimeError("exact infinity/nan is not supported: %s", n)
+	}
+	return newRatnum(new(big.Rat).SetFloat64(n.flonum))
+}
+
+func (n *Number) toInexact() *Number {
+	if !n.isExact() {
+		return n
+	}
+	return NewNumber(n.float())
+}
+
+// Returns the sign of the number, or 0 for NaN.
+func (n *Number) sign() int {
+	switch n.kind {
+	case fixnumKind:
+		switch {
+		case n.value > 0:
+			return 1
+		case n.value < 0:
+			return -1
+		}
+		return 0
+	case bignumKind:
+		return n.bignum.Sign()
+	case ratnumKind:
+		return n.ratnum.Sign()
+	default:
+		switch {
+		case n.flonum > 0:
+			return 1
+		case n.flonum < 0:
+			return -1
+		}
+		return 0
+	}
+}
+
+func (n *Number) add(m *Number) *Number {
+	switch {
+	case n.kind == flonumKind || m.kind == flonumKind:
+		return NewNumber(n.float() + m.float())
+	case n.kind == fixnumKind && m.kind == fixnumKind:
+		sum := n.value + m.value
+		if (sum > n.value) == (m.value > 0) {
+			return NewNumber(sum)
+		}
+		return newBignum(new(big.Int).Add(n.bigInt(), m.bigInt()))
+	case n.isInteger() && m.isInteger():
+		return newBignum(new(big.Int).Add(n.bigInt(), m.bigInt()))
+	default:
+		return newRatnum(new(big.Rat).Add(n.bigRat(), m.bigRat()))
+	}
+}
+
+func (n *Number) subtract(m *Number) *Number {
+	switch {
+	case n.kind == flonumKind || m.kind == flonumKind:
+		return NewNumber(n.float() - m.float())
+	case n.kind == fixnumKind && m.kind == fixnumKind:
+		difference := n.value - m.value
+		if (difference < n.value) == (m.value > 0) {
+			return NewNumber(difference)
+		}
+		return newBignum(new(big.Int).Sub(n.bigInt(), m.bigInt()))
+	case n.isInteger() && m.isInteger():
+		return newBignum(new(big.Int).Sub(n.bigInt(), m.bigInt()))
+	default:
+		return newRatnum(new(big.Rat).Sub(n.bigRat(), m.bigRat()))
+	}
+}
+
+func (n *Number) multiply(m *Number) *Number {
+	switch {
+	case n.kind == flonumKind || m.kind == flonumKind:
+		return NewNumber(n.float() * m.float())
+	case n.kind == fixnumKind && m.kind == fixnumKind:
+		product := n.value * m.value
+		if n.value == 0 || (product/n.value == m.value && !(n.value == -1 && m.value == minInt)) {
+			return NewNumber(product)
+		}
+		return newBignum(new(big.Int).Mul(n.bigInt(), m.bigInt()))
+	case n.isInteger() && m.isInteger():
+		return newBignum(new(big.Int).Mul(n.bigInt(), m.bigInt()))
+	default:
+		return newRatnum(new(big.Rat).Mul(n.bigRat(), m.bigRat()))
+	}
+}
+
+// Division of exact numbers is exact, so (/ 1 3) returns 1/3.
+func (n *Number) divide(m *Number) *Number {
+	if n.kind == flonumKind || m.kind == flonumKind {
+		return NewNumber(n.float() / m.float())
+	}
+	if m.sign() == 0 {
+		runtimeError("attempt to calculate a division by zero")
+	}
+	return newRatnum(new(big.Rat).Quo(n.bigRat(), m.bigRat()))
+}
+
+// Returns -1, 0 or 1 by comparison of two numbers.
+// An inexact operand makes comparison by float64.
+func (n *Number) compare(m *Number) int {
+	switch {
+	case n.kind == fixnumKind && m.kind == fixnumKind:
+		switch {
+		case n.value < m.value:
+			return -1
+		case n.value > m.value:
+			return 1
+		}
+		return 0
+	case n.kind == flonumKind || m.kind == flonumKind:
+		switch a, b := n.float(), m.float(); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	default:
+		return n.bigRat().Cmp(m.bigRat())
+	}
+}
+
+// Two numbers are eqv when they have the same exactness and are equal.
+func (n *Number) isEqv(m *Number) bool {
+	if n.isExact() != m.isExact() {
+		return false
+	}
+	if !n.isExact() {
+		return n.flonum == m.flonum
+	}
+	return n.compare(m) == 0
+}
+
+// Returns true when the number is NaN, which is not comparable.
+func (n *Number) isNaN() bool {
+	return n.kind == flonumKind && math.IsNaN(n.flonum)
+}
+
+func (n *Number) negate() *Number {
+	if n.kind == flonumKind {
+		return NewNumber(-n.flonum)
+	}
+	return NewNumber(0).subtract(n)
+}
+
+func (n *Number) abs() *Number {
+	if n.kind == flonumKind {
+		return NewNumber(math.Abs(n.flonum))
+	} else if n.sign() < 0 {
+		return n.negate()
+	}
+	return n
+}
+
+// Returns quotient and remainder of integer division.
+// Quotient is rounded toward negative infinity when floor is true,
+// otherwise toward zero.
+func (n *Number) divideInteger(m *Number, floor bool) (*Number, *Number) {
+	if !n.isExact() || !m.isExact() {
+		a, b := n.float(), m.float()
+		quotient := math.Trunc(a / b)
+		if floor {
+			quotient = math.Floor(a / b)
+		}
+		return NewNumber(quotient), NewNumber(a - b*quotient)
+	}
+	if m.sign() == 0 {
+		runtimeError("attempt to calculate a division by zero")
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(n.bigInt(), m.bigInt(), new(big.Int))
+	if floor && remainder.Sign() != 0 && remainder.Sign() != m.sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+		remainder.Add(remainder, m.bigInt())
+	}
+	return newBignum(quotient), newBignum(remainder)
+}
+
+// Rounds the number to an integer, keeping its exactness.
+// roundRound rounds to even when the number is halfway between two integers.
+func (n *Number) round(mode roundingMode) *Number {
+	switch n.kind {
+	case fixnumKind, bignumKind:
+		return n
+	case flonumKind:
+		switch mode {
+		case roundFloor:
+			return NewNumber(math.Floor(n.flonum))
+		case roundCeiling:
+			return NewNumber(math.Ceil(n.flonum))
+		case roundTruncate:
+			return NewNumber(math.Trunc(n.flonum))
+		default:
+			return NewNumber(math.RoundToEven(n.flonum))
+		}
+	}
+
+	// Denominator of big.Rat is positive, so that Div is floor division.
+	floor := new(big.Int).Div(n.ratnum.Num(), n.ratnum.Denom())
+	ceiling := new(big.Int).Add(floor, big.NewInt(1))
+	switch mode {
+	case roundFloor:
+		return newBignum(floor)
+	case roundCeiling:
+		return newBignum(ceiling)
+	case roundTruncate:
+		if n.sign() > 0 {
+			return newBignum(floor)
+		}
+		return newBignum(ceiling)
+	}
+
+	// Compare fraction part with 1/2.
+	fraction := new(big.Int).Sub(n.ratnum.Num(), new(big.Int).Mul(floor, n.ratnum.Denom()))
+	switch fraction.Lsh(fraction, 1).Cmp(n.ratnum.Denom()) {
+	case -1:
+		return newBignum(floor)
+	case 1:
+		return newBignum(ceiling)
+	}
+	if floor.Bit(0) == 0 {
+		return newBignum(floor)
+	}
+	return newBignum(ceiling)
+}
+
+// Exact number raised to exact integer power is exact.
+func (n *Number) expt(m *Number) *Number {
+	if !n.isExact() || !m.isExact() || !m.isInteger() {
+		return NewNumber(math.Pow(n.float(), m.float()))
+	}
+	if m.sign() < 0 {
+		return NewNumber(1).divide(n.expt(m.negate()))
+	}
+
+	base := n.bigRat()
+	numerator := new(big.Int).Exp(base.Num(), m.bigInt(), nil)
+	denominator := new(big.Int).Exp(base.Denom(), m.bigInt(), nil)
+	return newRatnum(new(big.Rat).SetFrac(numerator, denominator))
+}
+
+// Square root of exact number is exact when it is a perfect square.
+func (n *Number) sqrt() *Number {
+	if n.isExact() && n.sign() >= 0 {
+		value := n.bigRat()
+		numerator := new(big.Int).Sqrt(value.Num())
+		denominator := new(big.Int).Sqrt(value.Denom())
+		root := new(big.Rat).SetFrac(numerator, denominator)
+		if new(big.Rat).Mul(root, root).Cmp(value) == 0 {
+			return newRatnum(root)
+		}
+	}
+	return NewNumber(math.Sqrt(n.float()))
+}
+
+// Returns s and r such that n = s^2 + r, for exact non-negative integer n.
+func (n *Number) exactIntegerSqrt() (*Number, *Number) {
+	root := new(big.Int).Sqrt(n.bigInt())
+	rest := new(big.Int).Sub(n.bigInt(), new(big.Int).Mul(root, root))
+	return newBignum(root), newBignum(rest)
+}
+
+// Returns greatest common divisor of integers, which is inexact
+// when either of them is inexact.
+func (n *Number) gcd(m *Number) *Number {
+	a, b := n.toExact().abs().bigInt(), m.toExact().abs().bigInt()
+	divisor := newBignum(new(big.Int).GCD(nil, nil, a, b))
+	if !n.isExact() || !m.isExact() {
+		return divisor.toInexact()
+	}
+	return divisor
+}
+
+// Returns least common multiple of integers, which is inexact
+// when either of them is inexact.
+func (n *Number) lcm(m *Number) *Number {
+	if n.sign() == 0 || m.sign() == 0 {
+		return NewNumber(0).multiply(n).multiply(m)
+	}
+	quotient, _ := n.multiply(m).abs().divideInteger(n.gcd(m), false)
+	return quotient
+}
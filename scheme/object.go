@@ -4,12 +4,16 @@
 
 package scheme
 
+import "text/scanner"
+
 // Object is an abstruct class for scheme object.
 type Object interface {
 	Parent() Object
 	Bounder() *Variable
+	Position() *scanner.Position
 	setParent(Object)
 	setBounder(*Variable)
+	setPosition(*scanner.Position)
 	Eval() Object
 	String() string
 	isNumber() bool
@@ -22,13 +26,10 @@ type Object interface {
 	isSymbol() bool
 	isSyntax() bool
 	isString() bool
+	isChar() bool
+	isVector() bool
 	isVariable() bool
 	isApplication() bool
-	define(string, Object)
-	set(string, Object)
-	scopedBinding() Binding
-	binding() Binding
-	boundedObject(string) Object
 }
 
 // Binding is an abstruct type for binding.
@@ -36,8 +37,9 @@ type Binding map[string]Object
 
 // ObjectBase is an abstruct class for base scheme object.
 type ObjectBase struct {
-	parent  Object
-	bounder *Variable // Variable.Eval() sets itself into this
+	parent   Object
+	bounder  *Variable         // Variable.Eval() sets itself into this
+	position *scanner.Position // nil when the object is not parsed from source
 }
 
 // Eval is object's eval IF.
@@ -95,12 +97,16 @@ func (o *ObjectBase) isString() bool {
 	return false
 }
 
-func (o *ObjectBase) isApplication() bool {
+func (o *ObjectBase) isChar() bool {
 	return false
 }
 
-func (o *ObjectBase) binding() Binding {
-	return Binding{}
+func (o *ObjectBase) isVector() bool {
+	return false
+}
+
+func (o *ObjectBase) isApplication() bool {
+	return false
 }
 
 // Bounder is IF that returns object's bounder.
@@ -113,6 +119,15 @@ func (o *ObjectBase) Parent() Object {
 	return o.parent
 }
 
+// Position is IF that returns object's position in source code.
+func (o *ObjectBase) Position() *scanner.Position {
+	return o.position
+}
+
+func (o *ObjectBase) setPosition(position *scanner.Position) {
+	o.position = position
+}
+
 func (o *ObjectBase) setParent(parent Object) {
 	o.parent = parent
 }
@@ -120,52 +135,3 @@ func (o *ObjectBase) setParent(parent Object) {
 func (o *ObjectBase) setBounder(bounder *Variable) {
 	o.bounder = bounder
 }
-
-func (o *ObjectBase) scopedBinding() (scopedBinding Binding) {
-	scopedBinding = make(Binding)
-	parent := o.Parent()
-
-	for parent != nil {
-		for identifier, object := range parent.binding() {
-			if scopedBinding[identifier] == nil {
-				scopedBinding[identifier] = object
-			}
-		}
-		parent = parent.Parent()
-	}
-	return
-}
-
-// Define variable in the most inner closure.
-func (o *ObjectBase) define(identifier string, object Object) {
-	if o.parent == nil {
-		runtimeError("Bind called for object whose parent is nil")
-	}
-	o.parent.define(identifier, object)
-}
-
-// This is for set! syntax.
-// Update the variable's value when it is defined.
-func (o *ObjectBase) set(identifier string, object Object) {
-	if o.parent == nil {
-		runtimeError("symbol not defined")
-	} else {
-		o.parent.set(identifier, object)
-	}
-}
-
-func (o *ObjectBase) boundedObject(identifier string) Object {
-	scopedBinding := make(Binding)
-	parent := o.Parent()
-
-	for parent != nil {
-		for identifier, object := range parent.binding() {
-			if scopedBinding[identifier] == nil {
-				scopedBinding[identifier] = object
-			}
-		}
-		parent = parent.Parent()
-	}
-
-	return scopedBinding[identifier]
-}
@@ -10,6 +10,7 @@ package scheme
 import (
 	"fmt"
 	"strings"
+	"text/scanner"
 )
 
 var (
@@ -22,6 +23,9 @@ type Pair struct {
 	ObjectBase
 	Car Object
 	Cdr Object
+	// Position of car in source code, because symbols are shared and
+	// do not have position.
+	carPosition *scanner.Position
 }
 
 // NewPair is creating clear pair.
@@ -46,6 +50,8 @@ func (p *Pair) Eval() Object {
 func (p *Pair) String() string {
 	if p.isNull() {
 		return "()"
+	} else if abbreviation, ok := p.abbreviation(); ok {
+		return abbreviation + p.Cdr.(*Pair).Car.String()
 	} else if p.isList() {
 		length := p.ListLength()
 		tokens := []string{}
@@ -54,8 +60,27 @@ func (p *Pair) String() string {
 		}
 		return fmt.Sprintf("(%s)", strings.Join(tokens, " "))
 	} else {
-		return fmt.Sprintf("(%s . %s)", p.Car, p.Cdr)
+		tokens := []string{}
+		var object Object = p
+		for ; object.isPair(); object = object.(*Pair).Cdr {
+			tokens = append(tokens, object.(*Pair).Car.String())
+		}
+		return fmt.Sprintf("(%s . %s)", strings.Join(tokens, " "), object)
+	}
+}
+
+// Returns the abbreviation of the list such as (quote x), which is printed as 'x.
+func (p *Pair) abbreviation() (string, bool) {
+	symbol, ok := p.Car.(*Symbol)
+	if !ok || !p.Cdr.isPair() || !p.Cdr.(*Pair).Cdr.isNull() {
+		return "", false
+	}
+	for abbreviation, keyword := range abbreviations {
+		if symbol.identifier == keyword {
+			return abbreviation, true
+		}
 	}
+	return "", false
 }
 
 func (p *Pair) isNull() bool {
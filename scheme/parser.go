@@ -1,10 +1,21 @@
-// Parser is a type to analyze scheme source's syntax.
+// Parser is a type to read scheme source as data.
 // It embeds Lexer to generate tokens from a source code.
-// Parser.Parse() does syntactic analysis and returns scheme object pointer.
+// Parser.Parse() returns a datum, such as list, symbol and number, which
+// is converted to AST by datumToForm() before evaluation.
 
 package scheme
 
-// Parser is a struction for analyze scheme source's syntax.
+import "text/scanner"
+
+// Abbreviations of syntax forms, such as 'x for (quote x).
+var abbreviations = map[string]string{
+	"'":  "quote",
+	"`":  "quasiquote",
+	",":  "unquote",
+	",@": "unquote-splicing",
+}
+
+// Parser is a struction for reading scheme source as data.
 type Parser struct {
 	*Lexer
 }
@@ -14,100 +25,138 @@ func NewParser(source string) *Parser {
 	return &Parser{NewLexer(source)}
 }
 
-// Parse is a function to deal parser.
-func (p Parser) Parse(parent Object) Object {
+// Parse returns the next datum, or nil at the end of source code.
+func (p Parser) Parse() Object {
 	p.ensureAvailability()
-	return p.parseObject(parent)
+	return p.parseObject()
 }
 
-func (p *Parser) parseObject(parent Object) Object {
+// ParseForm returns the next form in AST, which is converted from the datum.
+func (p Parser) ParseForm() Object {
+	position := p.TokenPosition()
+	datum := p.Parse()
+	if datum == nil {
+		return nil
+	}
+	return datumToFormAt(datum, &position)
+}
+
+// Returns nil for close parenthesis and the end of source code.
+// Other tokens which do not start a datum, such as | and #foo, raise error.
+func (p *Parser) parseObject() Object {
+	if err := p.peekToken().err; err != nil {
+		p.NextToken()
+		panic(err)
+	}
 	tokenType := p.TokenType()
+	position := p.TokenPosition()
 	token := p.NextToken()
 
 	switch tokenType {
 	case '(':
-		return p.parseApplication(parent)
-	case '\'':
-		return p.parseSingleQuote(parent)
-	case IntToken:
-		return NewNumber(token, parent)
+		if p.PeekToken() == ")" {
+			p.NextToken()
+			return Null
+		}
+		return withPosition(p.parseList(), position)
+	case '\'', '`', ',':
+		return withPosition(p.parseAbbreviation(abbreviations[token], position), position)
+	case IntToken, NumberToken:
+		number := parseNumber(token)
+		if number == nil {
+			runtimeError("invalid number literal: %s", token)
+		}
+		return withPosition(number, position)
 	case IdentifierToken:
-		return NewVariable(token, parent)
+		return NewSymbol(token)
 	case BooleanToken:
-		return NewBoolean(token, parent)
+		return withPosition(NewBoolean(token), position)
+	case CharToken:
+		return withPosition(NewChar(token), position)
+	case VectorToken:
+		return withPosition(p.parseVector(), position)
+	case BytevectorToken:
+		return withPosition(p.parseBytevector(), position)
 	case StringToken:
-		return NewString(token[1:len(token)-1], parent)
+		return withPosition(NewString(parseString(token)), position)
+	case ')', EOF:
+		return nil
 	default:
+		runtimeError("unexpected token: %s", token)
 		return nil
 	}
 }
 
-// This is for parsing syntax sugar '*** => (quote ***)
-func (p *Parser) parseSingleQuote(parent Object) Object {
-	if len(p.PeekToken()) == 0 {
-		runtimeError("unterminated quote")
+// Record the position of the token to the parsed object.
+// Shared objects such as Null and Symbol do not have position.
+func withPosition(object Object, position scanner.Position) Object {
+	if object != Null && !object.isSymbol() {
+		object.setPosition(&position)
+	}
+	return object
+}
+
+// This is for parsing syntax sugar '*** => (quote ***),
+// `*** => (quasiquote ***), ,*** => (unquote ***) and ,@*** => (unquote-splicing ***)
+func (p *Parser) parseAbbreviation(keyword string, position scanner.Position) Object {
+	if len(p.PeekToken()) == 0 && p.peekToken().err == nil {
+		runtimeError("unterminated %s", keyword)
 	}
-	applicaton := NewApplication(parent)
-	applicaton.procedure = NewVariable("quote", applicaton)
-	applicaton.arguments = NewList(applicaton, p.parseObject(applicaton))
-	return applicaton
+	datumPosition := p.TokenPosition()
+	list := NewList(nil, NewSymbol(keyword), p.parseObject())
+	list.carPosition = &position
+	list.Cdr.(*Pair).carPosition = &datumPosition
+	return list
 }
 
 // This function returns *Pair of first object and list from second.
 // Scanner position ends with the next of close parentheses.
-func (p *Parser) parseList(parent Object) Object {
-	pair := NewPair(parent)
-	pair.Car = p.parseObject(pair)
+// The object after dot, such as b in (a . b), is returned as the last cdr.
+func (p *Parser) parseList() Object {
+	if p.TokenType() == '.' {
+		p.NextToken()
+		return p.parseDottedTail(p.parseObject())
+	}
+	pair := NewPair(nil)
+	position := p.TokenPosition()
+	pair.Car = p.parseObject()
 	if pair.Car == nil {
 		return pair
 	}
-	pair.Cdr = p.parseList(pair).(*Pair)
+	pair.carPosition = &position
+	pair.Cdr = p.parseList()
 	return pair
 }
 
-func (p *Parser) parseApplication(parent Object) Object {
-	if p.PeekToken() == ")" {
-		p.NextToken()
-		return Null
+// The object after dot must be followed by close parentheses.
+func (p *Parser) parseDottedTail(object Object) Object {
+	if object == nil || p.NextToken() != ")" {
+		runtimeError("bad dot syntax")
 	}
-	application := NewApplication(parent)
-	application.procedure = p.parseObject(application)
-	application.arguments = p.parseList(application)
-
-	return application
+	return object
 }
 
-func (p *Parser) parseQuotedObject(parent Object) Object {
-	tokenType := p.TokenType()
-	token := p.NextToken()
-
-	switch tokenType {
-	case '(':
-		return p.parseQuotedList(parent)
-	case '\'':
-		return p.parseSingleQuote(parent)
-	case IntToken:
-		return NewNumber(token, parent)
-	case IdentifierToken:
-		return NewSymbol(token)
-	case BooleanToken:
-		return NewBoolean(token, parent)
-	case ')':
-		return nil
-	default:
-		runtimeError("unterminated quote")
-		return nil
+// Scanner position ends with the next of close parentheses.
+func (p *Parser) parseVector() Object {
+	vector := NewVector([]Object{})
+	for {
+		element := p.parseObject()
+		if element == nil {
+			return vector
+		}
+		vector.elements = append(vector.elements, element)
 	}
 }
 
-func (p *Parser) parseQuotedList(parent Object) Object {
-	pair := NewPair(parent)
-	pair.Car = p.parseQuotedObject(pair)
-	if pair.Car == nil {
-		return pair
+func (p *Parser) parseBytevector() Object {
+	bytevector := NewBytevector([]byte{})
+	for {
+		element := p.parseObject()
+		if element == nil {
+			return bytevector
+		}
+		bytevector.bytes = append(bytevector.bytes, byteValue(element))
 	}
-	pair.Cdr = p.parseQuotedList(pair).(*Pair)
-	return pair
 }
 
 func (p *Parser) ensureAvailability() {
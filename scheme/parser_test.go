@@ -13,6 +13,21 @@ var parserTests = []parserTest{
 	parseTest("''''hello", "''''hello"),
 	parseTest("( x 1 )", "(x 1)"),
 	parseTest("( x ( 1 ) )", "(x (1))"),
+	parseTest("(lambda (x . y) y)", "(lambda (x . y) y)"),
+	parseTest("'(1 2 . 3)", "'(1 2 . 3)"),
+	parseTest("`(a ,b ,@c)", "`(a ,b ,@c)"),
+	parseTest("(quasiquote (unquote x))", "`,x"),
+	parseTest("'(\"a b\" #(c 'd))", "'(\"a b\" #(c 'd))"),
+	parseTest("`(a . ,b)", "`(a unquote b)"),
+	parseTest("'(a #true #false)", "'(a #t #f)"),
+}
+
+var parserErrorTests = []parserTest{
+	parseTest("'|foo bar|", "unexpected token: |"),
+	parseTest("'(a #foo)", "unknown token: #foo"),
+	parseTest("'#foo", "unknown token: #foo"),
+	parseTest("(a . b c)", "bad dot syntax"),
+	parseTest("\"abc", "unterminated string literal"),
 }
 
 func parseTest(source string, results ...string) parserTest {
@@ -24,7 +39,7 @@ func TestParser(t *testing.T) {
 		i := NewInterpreter(test.source)
 		parseResults := []string{}
 		for i.Peek() != EOF {
-			object := i.Parse(i.closure)
+			object := i.Parse()
 			if object != nil {
 				parseResults = append(parseResults, object.String())
 			}
@@ -39,3 +54,23 @@ func TestParser(t *testing.T) {
 		}
 	}
 }
+
+func TestParserError(t *testing.T) {
+	for _, test := range parserErrorTests {
+		actual := parseError(test.source)
+		if actual != test.results[0] {
+			t.Errorf("%s =>\n got: %s\nwant: %s", test.source, actual, test.results[0])
+		}
+	}
+}
+
+// Returns the message of error raised by parsing the source.
+func parseError(source string) (message string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			message = recoveredError(recovered).Error()
+		}
+	}()
+	NewParser(source).Parse()
+	return ""
+}
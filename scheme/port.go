@@ -0,0 +1,231 @@
+// Port is a type for scheme port, which is a source of characters for
+// input or a destination of them for output.
+// An input port wraps Go's io.Reader, and an output port wraps io.Writer.
+// A file port also closes its file, and a closed port cannot be read or written.
+// A string port reads from a string, or writes to a buffer in memory.
+
+package scheme
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+)
+
+// Ports which are used when a procedure is not given a port.
+var (
+	currentInputPort  = NewInputPort("stdin", os.Stdin)
+	currentOutputPort = NewOutputPort("stdout", os.Stdout)
+	currentErrorPort  = NewOutputPort("stderr", os.Stderr)
+)
+
+// Port is a struction for scheme port.
+type Port struct {
+	ObjectBase
+	name   string
+	source io.Reader
+	reader *bufio.Reader
+	writer io.Writer
+	closer io.Closer
+	closed bool
+	// Position of the next character to be read.
+	position scanner.Position
+}
+
+// NewInputPort is a function for definition a new input Port.
+func NewInputPort(name string, reader io.Reader) *Port {
+	position := scanner.Position{Filename: name, Line: 1, Column: 1}
+	return &Port{name: name, source: reader, reader: bufio.NewReader(reader), position: position}
+}
+
+// NewOutputPort is a function for definition a new output Port.
+func NewOutputPort(name string, writer io.Writer) *Port {
+	return &Port{name: name, writer: writer}
+}
+
+// Returns the input port of the file.
+func openInputFile(filename string) *Port {
+	file, err := os.Open(filename)
+	if err != nil {
+		runtimeError("cannot open file %q: %s", filename, err)
+	}
+	port := NewInputPort(filename, file)
+	port.closer = file
+	return port
+}
+
+// Returns the output port of the file, which is truncated if it exists.
+func openOutputFile(filename string) *Port {
+	file, err := os.Create(filename)
+	if err != nil {
+		runtimeError("cannot open file %q: %s", filename, err)
+	}
+	port := NewOutputPort(filename, file)
+	port.closer = file
+	return port
+}
+
+// Returns the input port which reads the text.
+func openInputString(text string) *Port {
+	return NewInputPort("string", strings.NewReader(text))
+}
+
+// Returns the output port which accumulates characters in memory.
+func openOutputString() *Port {
+	return NewOutputPort("string", new(strings.Builder))
+}
+
+// Eval is Port's eval IF.
+func (p *Port) Eval() Object {
+	return p
+}
+
+func (p *Port) String() string {
+	if p.isInput() {
+		return fmt.Sprintf("#<input-port %s>", p.name)
+	}
+	return fmt.Sprintf("#<output-port %s>", p.name)
+}
+
+func (p *Port) isInput() bool {
+	return p.reader != nil
+}
+
+func (p *Port) isOutput() bool {
+	return p.writer != nil
+}
+
+// Close the port. Closing a closed port has no effect.
+func (p *Port) close() {
+	if p.closed {
+		return
+	}
+	p.closed = true
+	if p.closer != nil {
+		if err := p.closer.Close(); err != nil {
+			runtimeError("cannot close %s: %s", p, err)
+		}
+	}
+}
+
+// Returns characters written to the string port so far, or false when
+// the port is not a string port.
+func (p *Port) outputString() (string, bool) {
+	if builder, ok := p.writer.(*strings.Builder); ok {
+		return builder.String(), true
+	}
+	return "", false
+}
+
+func (p *Port) assertOpen() {
+	if p.closed {
+		runtimeError("port is closed: %s", p)
+	}
+}
+
+// Returns the next character, or false at the end of input.
+func (p *Port) readRune() (rune, bool) {
+	char, ok := p.peekRune()
+	if ok {
+		p.reader.ReadRune()
+		p.advance(string(char))
+	}
+	return char, ok
+}
+
+// Returns the next character without consuming it, or false at the end of input.
+func (p *Port) peekRune() (rune, bool) {
+	p.assertOpen()
+	char, _, err := p.reader.ReadRune()
+	if err == io.EOF {
+		return 0, false
+	} else if err != nil {
+		runtimeError("cannot read from %s: %s", p, err)
+	}
+	p.reader.UnreadRune()
+	return char, true
+}
+
+// Move the position of the next character over the text which is read.
+func (p *Port) advance(text string) {
+	for _, char := range text {
+		p.position.Offset += utf8.RuneLen(char)
+		if char == '\n' {
+			p.position.Line++
+			p.position.Column = 1
+		} else {
+			p.position.Column++
+		}
+	}
+}
+
+// Returns the next line without its line ending, or false at the end of input.
+func (p *Port) readLine() (string, bool) {
+	p.assertOpen()
+	line, err := p.reader.ReadString('\n')
+	if err == io.EOF && len(line) == 0 {
+		return "", false
+	} else if err != nil && err != io.EOF {
+		runtimeError("cannot read from %s: %s", p, err)
+	}
+	p.advance(line)
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), true
+}
+
+// Returns at most count characters, or false at the end of input.
+func (p *Port) readString(count int) (string, bool) {
+	runes := []rune{}
+	for len(runes) < count {
+		char, ok := p.readRune()
+		if !ok {
+			break
+		}
+		runes = append(runes, char)
+	}
+	if len(runes) == 0 && count > 0 {
+		return "", false
+	}
+	return string(runes), true
+}
+
+// Returns true when a character can be read without blocking.
+// Files other than regular ones, such as terminal and pipe, may block
+// until input is available.
+func (p *Port) isReady() bool {
+	p.assertOpen()
+	if p.reader.Buffered() > 0 {
+		return true
+	} else if file, ok := p.source.(*os.File); ok {
+		info, err := file.Stat()
+		return err == nil && info.Mode().IsRegular()
+	}
+	return true
+}
+
+func (p *Port) write(text string) {
+	p.assertOpen()
+	if _, err := io.WriteString(p.writer, text); err != nil {
+		runtimeError("cannot write to %s: %s", p, err)
+	}
+}
+
+// EOFObject is a type for the object which is read at the end of input.
+type EOFObject struct {
+	ObjectBase
+}
+
+var eofObject = Object(&EOFObject{})
+
+// Eval is EOFObject's eval IF.
+func (e *EOFObject) Eval() Object {
+	return e
+}
+
+func (e *EOFObject) String() string {
+	return "#<eof>"
+}
@@ -0,0 +1,160 @@
+// Reader reads data from input ports for read procedure.
+// The text of a datum is taken from the port character by character,
+// so that characters after the datum are left in the port for other
+// input procedures. Then the text is parsed by Parser as datum.
+
+package scheme
+
+import (
+	"strings"
+	"text/scanner"
+	"unicode"
+)
+
+// Characters which end a datum such as symbol and number.
+const delimiters = "()'\";"
+
+// Returns the next datum of the port, or the eof object at the end of input.
+// Malformed input raises ReadError at the position of the datum.
+func (p *Port) read() Object {
+	if _, ok := p.skipAtmosphere(); !ok {
+		return eofObject
+	}
+	position := p.position
+	text, ok := p.readDatumText()
+	if !ok {
+		readError(position, "unexpected end of input: %s", text)
+	}
+	datum, message := parseDatum(text)
+	if message != "" {
+		readError(position, "%s: %s", message, text)
+	}
+	return datum
+}
+
+// Skip whitespaces and comments before datum, and returns whether
+// something is skipped, and false at the end of input.
+func (p *Port) skipAtmosphere() (bool, bool) {
+	for skipped := false; ; skipped = true {
+		char, ok := p.peekRune()
+		if !ok {
+			return skipped, false
+		} else if char == ';' {
+			p.readLine()
+		} else if unicode.IsSpace(char) {
+			p.readRune()
+		} else {
+			return skipped, true
+		}
+	}
+}
+
+// Returns the text of the next datum, and false when input ends within it.
+func (p *Port) readDatumText() (string, bool) {
+	text := &strings.Builder{}
+	depth := 0
+	for {
+		if depth > 0 || text.Len() > 0 {
+			// Datum continues after open parenthesis or abbreviation such as '.
+			if skipped, ok := p.skipAtmosphere(); !ok {
+				return text.String(), false
+			} else if skipped {
+				text.WriteRune(' ')
+			}
+		}
+		char, _ := p.readRune()
+		text.WriteRune(char)
+
+		switch char {
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+		case '\'', '`', ',':
+			continue
+		case '"':
+			if !p.readStringText(text) {
+				return text.String(), false
+			}
+		case '#':
+			if next, ok := p.peekRune(); ok && next == '\\' {
+				// The character after #\ may be a delimiter, such as #\( or #\space.
+				p.readRune()
+				text.WriteRune('\\')
+				if char, ok := p.readRune(); ok {
+					text.WriteRune(char)
+				}
+				p.readAtomText(text)
+			} else if atom := p.readAtomText(text); atom == "" || atom == "u8" {
+				// The vector or bytevector starts with the next parenthesis.
+				continue
+			}
+		default:
+			p.readAtomText(text)
+		}
+		if depth <= 0 {
+			return text.String(), true
+		}
+	}
+}
+
+// Read characters of string literal after the opening double quote,
+// and returns false when input ends before the closing one.
+func (p *Port) readStringText(text *strings.Builder) bool {
+	for {
+		char, ok := p.readRune()
+		if !ok {
+			return false
+		}
+		text.WriteRune(char)
+		if char == '\\' {
+			if char, ok = p.readRune(); ok {
+				text.WriteRune(char)
+			}
+		} else if char == '"' {
+			return true
+		}
+	}
+}
+
+// Read characters until a delimiter, and returns them.
+func (p *Port) readAtomText(text *strings.Builder) string {
+	atom := []rune{}
+	for {
+		char, ok := p.peekRune()
+		if !ok || unicode.IsSpace(char) || strings.ContainsRune(delimiters, char) {
+			text.WriteString(string(atom))
+			return string(atom)
+		}
+		p.readRune()
+		atom = append(atom, char)
+	}
+}
+
+// Parse the text as datum, and returns the message of error on failure.
+// Exception handlers are not called by errors of parser, which are
+// reported as ReadError by the caller.
+func parseDatum(text string) (datum Object, message string) {
+	handlers := running.exceptionHandlers
+	running.exceptionHandlers = []Object{}
+	defer func() {
+		running.exceptionHandlers = handlers
+		if recovered := recover(); recovered != nil {
+			datum, message = nil, recoveredError(recovered).Error()
+		}
+	}()
+
+	parser := NewParser(text)
+	datum = parser.Parse()
+	if datum == nil || parser.PeekToken() != "" {
+		return nil, "invalid datum"
+	}
+	return datum, ""
+}
+
+func readError(position scanner.Position, format string, a ...interface{}) {
+	base := newErrorBase("", nil, format, a...)
+	base.position = &position
+	raise(&ReadError{base}, false)
+}
@@ -0,0 +1,75 @@
+// Record is a type for scheme record object, whose type is defined by
+// define-record-type. A record has fields of its record type, and
+// it is printed like #<point x: 1 y: 2>.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RecordType is a struction for record type, which is bound to
+// the type name such as <point>.
+type RecordType struct {
+	ObjectBase
+	name   string
+	fields []string
+}
+
+// Record is a struction for instance of a record type.
+type Record struct {
+	ObjectBase
+	recordType *RecordType
+	values     []Object
+}
+
+// NewRecordType is a function for definition a new RecordType object.
+// Angle brackets of the type name are removed, so <point> is named point.
+func NewRecordType(name string, fields []string) *RecordType {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") && len(name) > 2 {
+		name = name[1 : len(name)-1]
+	}
+	return &RecordType{name: name, fields: fields}
+}
+
+// Eval is record type's eval IF.
+func (r *RecordType) Eval() Object {
+	return r
+}
+
+func (r *RecordType) String() string {
+	return fmt.Sprintf("#<record-type %s>", r.name)
+}
+
+// Returns the index of the field, or -1 when the field is not defined.
+func (r *RecordType) fieldIndex(field string) int {
+	for index, name := range r.fields {
+		if name == field {
+			return index
+		}
+	}
+	return -1
+}
+
+// Raise type error unless the object is a record of this type.
+func (r *RecordType) assertRecord(object Object) *Record {
+	record, ok := object.(*Record)
+	if !ok || record.recordType != r {
+		typeError(object, "%s required, but got %s", r.name, object)
+	}
+	return record
+}
+
+// Eval is record's eval IF.
+func (r *Record) Eval() Object {
+	return r
+}
+
+func (r *Record) String() string {
+	texts := []string{r.recordType.name}
+	for index, field := range r.recordType.fields {
+		texts = append(texts, fmt.Sprintf("%s: %s", field, r.values[index]))
+	}
+	return fmt.Sprintf("#<%s>", strings.Join(texts, " "))
+}
@@ -1,9 +1,29 @@
 // String is a type for scheme string object, which is
 // expressed like "string".
+// String literal can contain escape sequences such as \n, \" and \x41;.
+// String object is mutable by string-set! and string-fill!.
 
 package scheme
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Escape sequences of string literal except \x<hex>; and line continuation.
+var stringEscapes = map[rune]rune{
+	'a':  '\a',
+	'b':  '\b',
+	't':  '\t',
+	'n':  '\n',
+	'r':  '\r',
+	'"':  '"',
+	'\\': '\\',
+	'|':  '|',
+}
 
 // String is a struction for scheme string object.
 type String struct {
@@ -28,13 +48,83 @@ func NewString(object interface{}, options ...Object) *String {
 	return &String{text: text}
 }
 
+// Decode string literal token, which is enclosed by double quotes.
+func parseString(token string) string {
+	runes := []rune(token[1 : len(token)-1])
+	text := []rune{}
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' {
+			text = append(text, runes[i])
+			continue
+		}
+
+		i++
+		if i == len(runes) {
+			runtimeError("invalid escape sequence in string: %s", token)
+		} else if char, ok := stringEscapes[runes[i]]; ok {
+			text = append(text, char)
+		} else if runes[i] == 'x' || runes[i] == 'X' {
+			end := i + 1
+			for end < len(runes) && runes[end] != ';' {
+				end++
+			}
+			value, err := strconv.ParseUint(string(runes[i+1:end]), 16, 32)
+			if end == len(runes) || err != nil || !utf8.ValidRune(rune(value)) {
+				runtimeError("invalid escape sequence in string: %s", token)
+			}
+			text = append(text, rune(value))
+			i = end
+		} else {
+			i = skipLineContinuation(runes, i, token)
+		}
+	}
+	return string(text)
+}
+
+// \<intraline whitespace>*<newline><intraline whitespace>* is skipped.
+// Returns the index of the last skipped character.
+func skipLineContinuation(runes []rune, index int, token string) int {
+	for index < len(runes) && (runes[index] == ' ' || runes[index] == '\t') {
+		index++
+	}
+	if index == len(runes) || runes[index] != '\n' {
+		runtimeError("invalid escape sequence in string: %s", token)
+	}
+	index++
+	for index < len(runes) && (runes[index] == ' ' || runes[index] == '\t') {
+		index++
+	}
+	return index - 1
+}
+
 // Eval is string's eval IF.
 func (s *String) Eval() Object {
 	return s
 }
 
+// String returns the written form, which can be read as the same string.
 func (s *String) String() string {
-	return fmt.Sprintf("\"%s\"", s.text)
+	var builder strings.Builder
+	builder.WriteRune('"')
+	for _, char := range s.text {
+		switch {
+		case char == '"' || char == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(char)
+		case char == '\n':
+			builder.WriteString("\\n")
+		case char == '\t':
+			builder.WriteString("\\t")
+		case char == '\r':
+			builder.WriteString("\\r")
+		case !unicode.IsGraphic(char):
+			fmt.Fprintf(&builder, "\\x%x;", char)
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	builder.WriteRune('"')
+	return builder.String()
 }
 
 func (s *String) isString() bool {
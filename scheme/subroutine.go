@@ -9,6 +9,8 @@ import (
 type Subroutine struct {
 	ObjectBase
 	function func(Object) Object
+	// Applied to two fixnums by the virtual machine, or nil.
+	fixnumPrimitive func(n, m int) Object
 }
 
 func NewSubroutine(function func(Object) Object) *Subroutine {
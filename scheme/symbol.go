@@ -3,9 +3,14 @@
 
 package scheme
 
+import "fmt"
+
 var (
 	symbols = make(map[string]*Symbol)
 	undef   = Object(&Symbol{identifier: "#<undef>"})
+
+	// The number of symbols generated by gensym.
+	gensymCount = 0
 )
 
 // Symbol is a struction for scheme symbol object.
@@ -22,6 +27,12 @@ func NewSymbol(identifier string, options ...Object) *Symbol {
 	return symbols[identifier]
 }
 
+// NewGensym is a function for definition a new symbol, which is not interned.
+func NewGensym(prefix string) *Symbol {
+	gensymCount++
+	return &Symbol{identifier: fmt.Sprintf("%s%d", prefix, gensymCount)}
+}
+
 // Eval is symbol's eval IF.
 func (s *Symbol) Eval() Object {
 	return s
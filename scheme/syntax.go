@@ -6,22 +6,45 @@ import "fmt"
 
 var (
 	builtinSyntaxes = Binding{
-		"set!":   NewSyntax(setSyntax),
-		"if":     NewSyntax(ifSyntax),
-		"lambda": NewSyntax(lambdaSyntax),
-		"let":    NewSyntax(letSyntax),
-		"let*":   NewSyntax(letSyntax),
-		"letrec": NewSyntax(letSyntax),
-		"and":    NewSyntax(andSyntax),
-		"or":     NewSyntax(orSyntax),
-		"quote":  NewSyntax(quoteSyntax),
-		"begin":  NewSyntax(beginSyntax),
-		"define": NewSyntax(defineSyntax),
-		"cond":   NewSyntax(condSyntax),
-		"do":     NewSyntax(doSyntax),
+		"set!":               NewSyntax(setSyntax),
+		"if":                 NewSyntax(ifSyntax),
+		"lambda":             NewSyntax(lambdaSyntax),
+		"let":                NewSyntax(letSyntax),
+		"let*":               NewSyntax(letStarSyntax),
+		"letrec":             NewSyntax(letrecSyntax),
+		"letrec*":            NewSyntax(letrecStarSyntax),
+		"let-values":         NewSyntax(letValuesSyntax),
+		"let*-values":        NewSyntax(letStarValuesSyntax),
+		"and":                NewSyntax(andSyntax),
+		"or":                 NewSyntax(orSyntax),
+		"quote":              NewSyntax(quoteSyntax),
+		"quasiquote":         NewSyntax(quasiquoteSyntax),
+		"unquote":            NewSyntax(unquoteSyntax),
+		"unquote-splicing":   NewSyntax(unquoteSyntax),
+		"begin":              NewSyntax(beginSyntax),
+		"define":             NewSyntax(defineSyntax),
+		"cond":               NewSyntax(condSyntax),
+		"guard":              NewSyntax(guardSyntax),
+		"do":                 NewSyntax(doSyntax),
+		"define-record-type": NewSyntax(defineRecordTypeSyntax),
+		"define-syntax":      NewSyntax(defineSyntaxSyntax),
+		"define-macro":       NewSyntax(defineMacroSyntax),
+		"let-syntax":         NewSyntax(letSyntaxSyntax),
+		"letrec-syntax":      NewSyntax(letrecSyntaxSyntax),
+		"syntax-rules":       NewSyntax(syntaxRulesSyntax),
 	}
 )
 
+// Keywords of builtin syntaxes, which are looked up by the syntax object.
+var syntaxKeywords map[Object]string
+
+func init() {
+	syntaxKeywords = map[Object]string{}
+	for keyword, syntax := range builtinSyntaxes {
+		syntaxKeywords[syntax] = keyword
+	}
+}
+
 // Syntax is a type for updating value.
 type Syntax struct {
 	ObjectBase
@@ -38,6 +61,12 @@ func (s *Syntax) Invoke(arguments Object) Object {
 	return s.function(s, arguments)
 }
 
+// Eval is Syntax's eval IF, which is applied to evaluated objects
+// when it is passed to procedures as an argument.
+func (s *Syntax) Eval() Object {
+	return s
+}
+
 func (s *Syntax) String() string {
 	return fmt.Sprintf("#<syntax %s>", s.Bounder())
 }
@@ -47,7 +76,7 @@ func (s *Syntax) isSyntax() bool {
 }
 
 func (s *Syntax) malformedError() {
-	syntaxError("malformed %s: %s", s.Bounder(), s.Bounder().Parent())
+	syntaxError(s.Bounder().Parent(), "malformed %s: %s", s.Bounder(), s.Bounder().Parent())
 }
 
 func (s *Syntax) assertListEqual(arguments Object, length int) {
@@ -84,7 +113,7 @@ func setSyntax(s *Syntax, arguments Object) Object {
 		s.malformedError()
 	}
 	value := elements[1].Eval()
-	s.Bounder().set(variable.(*Variable).identifier, value)
+	running.environment.set(variable.(*Variable), value)
 	return value
 }
 
@@ -95,12 +124,12 @@ func ifSyntax(s *Syntax, arguments Object) Object {
 	result := elements[0].Eval()
 	if result.isBoolean() && !result.(*Boolean).value {
 		if len(elements) == 3 {
-			return elements[2].Eval()
+			return evalTail(elements[2])
 		} else {
 			return undef
 		}
 	} else {
-		return elements[1].Eval()
+		return evalTail(elements[1])
 	}
 }
 
@@ -126,37 +155,50 @@ func applicationToList(object Object) Object {
 func andSyntax(s *Syntax, arguments Object) Object {
 	s.assertListMinimum(arguments, 0)
 
-	lastResult := Object(NewBoolean(true))
-	for _, object := range arguments.(*Pair).Elements() {
-		lastResult = object.Eval()
+	elements := arguments.(*Pair).Elements()
+	if len(elements) == 0 {
+		return NewBoolean(true)
+	}
+	for _, object := range elements[:len(elements)-1] {
+		lastResult := object.Eval()
 		if lastResult.isBoolean() && lastResult.(*Boolean).value == false {
 			return NewBoolean(false)
 		}
 	}
-	return lastResult
+	return evalTail(elements[len(elements)-1])
 }
 
 func orSyntax(s *Syntax, arguments Object) Object {
 	s.assertListMinimum(arguments, 0)
 
-	lastResult := Object(NewBoolean(false))
-	for _, object := range arguments.(*Pair).Elements() {
-		lastResult = object.Eval()
+	elements := arguments.(*Pair).Elements()
+	if len(elements) == 0 {
+		return NewBoolean(false)
+	}
+	for _, object := range elements[:len(elements)-1] {
+		lastResult := object.Eval()
 		if !lastResult.isBoolean() || lastResult.(*Boolean).value != false {
 			return lastResult
 		}
 	}
-	return lastResult
+	return evalTail(elements[len(elements)-1])
 }
 
 func beginSyntax(s *Syntax, arguments Object) Object {
 	s.assertListMinimum(arguments, 0)
+	return evalBody(arguments.(*Pair).Elements())
+}
 
-	lastResult := undef
-	for _, object := range arguments.(*Pair).Elements() {
-		lastResult = object.Eval()
+// Eval each object in order and returns the last result.
+// The last object is in tail position.
+func evalBody(objects []Object) Object {
+	if len(objects) == 0 {
+		return undef
+	}
+	for _, object := range objects[:len(objects)-1] {
+		object.Eval()
 	}
-	return lastResult
+	return evalTail(objects[len(objects)-1])
 }
 
 func defineSyntax(s *Syntax, arguments Object) Object {
@@ -164,41 +206,320 @@ func defineSyntax(s *Syntax, arguments Object) Object {
 	elements := arguments.(*Pair).Elements()
 
 	if !elements[0].isVariable() {
-		syntaxError("%s", s.Bounder().Parent())
+		syntaxError(s.Bounder().Parent(), "%s", s.Bounder().Parent())
+	}
+	variable := elements[0].(*Variable)
+	running.environment.define(variable.identifier, elements[1].Eval())
+
+	return NewSymbol(variable.identifier)
+}
+
+// (define-syntax keyword transformer) binds the macro to the keyword.
+// Its uses are expanded before evaluation, but the macro is bound also
+// here, so that the use which is not expanded yet can be expanded.
+func defineSyntaxSyntax(s *Syntax, arguments Object) Object {
+	s.assertListEqual(arguments, 2)
+	elements := arguments.(*Pair).Elements()
+
+	if !elements[0].isVariable() {
+		s.malformedError()
 	}
 	variable := elements[0].(*Variable)
-	s.Bounder().define(variable.identifier, elements[1].Eval())
+	running.environment.define(variable.identifier, evalTransformer(s.Bounder().Parent(), elements[1]))
 
 	return NewSymbol(variable.identifier)
 }
 
+// Eval the transformer of the syntax definition form.
+func evalTransformer(form Object, transformer Object) *Macro {
+	macro, ok := transformer.Eval().(*Macro)
+	if !ok {
+		syntaxError(form, "syntax-rules required, but got %s", transformer)
+	}
+	return macro
+}
+
+// (define-macro name transformer) binds the macro whose transformer is a
+// procedure, which takes forms of the macro use as data and returns the expansion.
+func defineMacroSyntax(s *Syntax, arguments Object) Object {
+	name, transformer := macroDefinition(s.Bounder().Parent())
+	running.environment.define(name.identifier, evalMacroTransformer(s.Bounder().Parent(), transformer))
+
+	return NewSymbol(name.identifier)
+}
+
+// Returns the name and the transformer of define-macro form.
+// (define-macro (name . parameters) body ...) is rewritten to
+// (define-macro name (lambda parameters body ...)).
+func macroDefinition(form Object) (*Variable, Object) {
+	elements, ok := formElements(form)
+	if !ok || len(elements) < 3 {
+		syntaxError(form, "malformed define-macro: %s", form)
+	}
+	if name, ok := elements[1].(*Variable); ok {
+		if len(elements) != 3 {
+			syntaxError(form, "malformed define-macro: %s", form)
+		}
+		return name, elements[2]
+	}
+
+	definition, ok := elements[1].(*Application)
+	if !ok || !definition.procedure.isVariable() {
+		syntaxError(form, "malformed define-macro: %s", form)
+	}
+	lambda := newForm(append([]Object{NewVariable("lambda", nil), definition.arguments}, elements[2:]...), form.Position())
+	application := form.(*Application)
+	application.arguments = NewList(application, definition.procedure, lambda)
+	definition.procedure.setParent(application)
+	lambda.setParent(application)
+	return definition.procedure.(*Variable), lambda
+}
+
+// Eval the transformer of define-macro, which must be a procedure.
+func evalMacroTransformer(form Object, transformer Object) *Macro {
+	procedure := transformer.Eval()
+	if !procedure.isProcedure() {
+		syntaxError(form, "procedure required for define-macro, but got %s", procedure)
+	}
+	return &Macro{transformer: procedure}
+}
+
+// (syntax-rules [ellipsis] (literal ...) (pattern template) ...)
+// returns the macro which transcribes its use by the rules.
+func syntaxRulesSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+
+	macro := &Macro{ellipsis: "..."}
+	if elements[0].isVariable() {
+		macro.ellipsis = elements[0].String()
+		elements = s.elementsMinimum(arguments.(*Pair).Cdr, 1)
+	}
+	for _, literal := range s.elementsMinimum(elements[0], 0) {
+		if !literal.isVariable() {
+			s.malformedError()
+		}
+		macro.literals = append(macro.literals, literal.String())
+	}
+	for _, rule := range elements[1:] {
+		ruleElements, ok := formElements(rule)
+		if !ok || len(ruleElements) != 2 || !ruleElements[0].isApplication() {
+			s.malformedError()
+		}
+		macro.rules = append(macro.rules, syntaxRule{pattern: ruleElements[0], template: ruleElements[1]})
+	}
+	return macro
+}
+
+// (define-record-type <name> (constructor field ...) predicate (field accessor [modifier]) ...)
+// defines the record type and procedures for it.
+func defineRecordTypeSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 3)
+	if !elements[0].isVariable() || !elements[2].isVariable() {
+		s.malformedError()
+	}
+
+	fieldSpecs := [][]Object{}
+	fields := []string{}
+	for _, element := range elements[3:] {
+		fieldSpec := s.elementsMinimum(element, 2)
+		for _, object := range fieldSpec {
+			if !object.isVariable() || len(fieldSpec) > 3 {
+				s.malformedError()
+			}
+		}
+		fieldSpecs = append(fieldSpecs, fieldSpec)
+		fields = append(fields, fieldSpec[0].(*Variable).identifier)
+	}
+	recordType := NewRecordType(elements[0].(*Variable).identifier, fields)
+	running.environment.define(elements[0].(*Variable).identifier, recordType)
+
+	// Constructor takes the listed fields, and others are undefined.
+	constructorSpec := s.elementsMinimum(elements[1], 1)
+	indices := []int{}
+	for _, object := range constructorSpec {
+		if !object.isVariable() {
+			s.malformedError()
+		}
+	}
+	for _, object := range constructorSpec[1:] {
+		index := recordType.fieldIndex(object.(*Variable).identifier)
+		if index < 0 {
+			syntaxError(s.Bounder().Parent(), "unknown field %s in %s", object, s.Bounder())
+		}
+		indices = append(indices, index)
+	}
+	running.environment.define(constructorSpec[0].(*Variable).identifier, NewSubroutine(func(arguments Object) Object {
+		assertListEqual(arguments, len(indices))
+		record := &Record{recordType: recordType, values: make([]Object, len(fields))}
+		for index := range record.values {
+			record.values[index] = undef
+		}
+		for index, value := range evaledObjects(arguments.(*Pair).Elements()) {
+			record.values[indices[index]] = value
+		}
+		return record
+	}))
+
+	running.environment.define(elements[2].(*Variable).identifier, NewSubroutine(func(arguments Object) Object {
+		return booleanByFunc(arguments, func(object Object) bool {
+			record, ok := object.(*Record)
+			return ok && record.recordType == recordType
+		})
+	}))
+
+	for index, fieldSpec := range fieldSpecs {
+		index := index
+		running.environment.define(fieldSpec[1].(*Variable).identifier, NewSubroutine(func(arguments Object) Object {
+			assertListEqual(arguments, 1)
+			return recordType.assertRecord(arguments.(*Pair).ElementAt(0).Eval()).values[index]
+		}))
+		if len(fieldSpec) == 3 {
+			running.environment.define(fieldSpec[2].(*Variable).identifier, NewSubroutine(func(arguments Object) Object {
+				assertListEqual(arguments, 2)
+				objects := evaledObjects(arguments.(*Pair).Elements())
+				recordType.assertRecord(objects[0]).values[index] = objects[1]
+				return undef
+			}))
+		}
+	}
+	return NewSymbol(elements[0].(*Variable).identifier)
+}
+
 func quoteSyntax(s *Syntax, arguments Object) Object {
 	s.assertListEqual(arguments, 1)
-	object := arguments.(*Pair).ElementAt(0)
+	return quotedDatum(arguments.Parent().(*Application))
+}
+
+// Returns the datum of (quote datum), which is read from source code.
+// The form made by macro expansion is converted back to the datum.
+func quotedDatum(application *Application) Object {
+	if application.datum != nil {
+		return application.datum.ElementAt(1)
+	}
+	return formToDatum(application.arguments.(*Pair).Car)
+}
+
+// Returns true when the object is the application of the builtin syntax,
+// such as (unquote x).
+func isSyntaxApplication(object Object, keyword string) bool {
+	application, ok := object.(*Application)
+	if !ok || !application.procedure.isVariable() {
+		return false
+	}
+	return syntaxKeywords[application.procedure.(*Variable).resolve()] == keyword
+}
+
+func quasiquoteSyntax(s *Syntax, arguments Object) Object {
+	s.assertListEqual(arguments, 1)
+	return s.quasiquote(arguments.(*Pair).ElementAt(0), 1)
+}
+
+// Build the datum from the template of quasiquote. Unquoted expressions
+// are evaluated when they are in the outermost quasiquote, where the depth is 1,
+// and the depth is changed by nested quasiquote and unquote.
+func (s *Syntax) quasiquote(template Object, depth int) Object {
+	switch {
+	case isSyntaxApplication(template, "unquote"):
+		if depth == 1 {
+			return s.unquotedObject(template)
+		}
+		return s.quasiquoteKeyword("unquote", template, depth-1)
+	case isSyntaxApplication(template, "unquote-splicing"):
+		if depth == 1 {
+			syntaxError(template, "unquote-splicing appeared in invalid context: %s", template)
+		}
+		return s.quasiquoteKeyword("unquote-splicing", template, depth-1)
+	case isSyntaxApplication(template, "quasiquote"):
+		return s.quasiquoteKeyword("quasiquote", template, depth+1)
+	}
+
+	switch template.(type) {
+	case *Application:
+		elements := []Object{template.(*Application).procedure}
+		tail := template.(*Application).arguments
+		for ; tail.isPair(); tail = tail.(*Pair).Cdr {
+			elements = append(elements, tail.(*Pair).Car)
+		}
+
+		list := Object(NewPair(nil))
+		if !tail.isNull() {
+			list = s.quasiquote(tail, depth)
+		}
+		objects := s.quasiquoteElements(elements, depth)
+		for i := len(objects) - 1; i >= 0; i-- {
+			pair := NewPair(nil)
+			pair.Car = objects[i]
+			pair.Cdr = list
+			list = pair
+		}
+		return list
+	case *Vector:
+		return NewVector(s.quasiquoteElements(template.(*Vector).elements, depth))
+	default:
+		return formToDatum(template)
+	}
+}
+
+// Elements of (unquote-splicing list) in the outermost quasiquote are
+// spliced into the list or vector.
+func (s *Syntax) quasiquoteElements(elements []Object, depth int) []Object {
+	objects := []Object{}
+	for _, element := range elements {
+		if depth == 1 && isSyntaxApplication(element, "unquote-splicing") {
+			list := s.unquotedObject(element)
+			if !list.isList() {
+				typeError(list, "list required for unquote-splicing, but got %s", list)
+			}
+			objects = append(objects, list.(*Pair).Elements()...)
+		} else {
+			objects = append(objects, s.quasiquote(element, depth))
+		}
+	}
+	return objects
+}
+
+// Build (keyword datum), such as (unquote x) in nested quasiquote.
+func (s *Syntax) quasiquoteKeyword(keyword string, template Object, depth int) Object {
+	s.assertListEqual(template.(*Application).arguments, 1)
+	elements := s.quasiquoteElements(template.(*Application).arguments.(*Pair).Elements(), depth)
+	return NewList(nil, append([]Object{NewSymbol(keyword)}, elements...)...)
+}
 
-	p := NewParser(object.String())
-	p.Peek()
-	return p.parseQuotedObject(s.Bounder())
+func (s *Syntax) unquotedObject(template Object) Object {
+	s.assertListEqual(template.(*Application).arguments, 1)
+	return template.(*Application).arguments.(*Pair).Car.Eval()
+}
+
+// unquote and unquote-splicing are valid only in quasiquote.
+func unquoteSyntax(s *Syntax, arguments Object) Object {
+	syntaxError(s.Bounder().Parent(), "%s appeared outside quasiquote: %s", s.Bounder(), s.Bounder().Parent())
+	return nil
 }
 
 func condSyntax(s *Syntax, arguments Object) Object {
 	elements := s.elementsMinimum(arguments, 0)
 	if len(elements) == 0 {
-		syntaxError("at least one clause is required for cond")
+		syntaxError(s.Bounder().Parent(), "at least one clause is required for cond")
 	}
 
+	result, _ := s.evalClauses(elements)
+	return result
+}
+
+// Eval clauses of cond and returns the result of the selected clause.
+// When no clause is selected, returns false as second value.
+func (s *Syntax) evalClauses(elements []Object) (Object, bool) {
 	// First: syntax check
 	elseExists := false
 	for _, element := range elements {
 		if elseExists {
-			syntaxError("'else' clause followed by more clauses")
-		} else if element.isApplication() && element.(*Application).procedure.isVariable() &&
-			element.(*Application).procedure.(*Variable).identifier == "else" {
+			syntaxError(s.Bounder().Parent(), "'else' clause followed by more clauses")
+		} else if element.isApplication() && isKeyword(element.(*Application).procedure, "else") {
 			elseExists = true
 		}
 
 		if element.isNull() || !element.isApplication() {
-			syntaxError("bad clause in cond")
+			syntaxError(s.Bounder().Parent(), "bad clause in cond")
 		}
 	}
 
@@ -207,71 +528,147 @@ func condSyntax(s *Syntax, arguments Object) Object {
 		lastResult := undef
 		application := element.(*Application)
 
-		isElse := application.procedure.isVariable() && application.procedure.(*Variable).identifier == "else"
+		isElse := isKeyword(application.procedure, "else")
 		if !isElse {
 			lastResult = application.procedure.Eval()
 		}
 
 		// first element is 'else' or not '#f'
 		if isElse || !lastResult.isBoolean() || lastResult.(*Boolean).value == true {
-			for _, object := range application.arguments.(*Pair).Elements() {
-				lastResult = object.Eval()
+			if application.arguments.(*Pair).isNull() {
+				return lastResult, true
 			}
-			return lastResult
+			return evalBody(application.arguments.(*Pair).Elements()), true
+		}
+	}
+	return undef, false
+}
+
+func guardSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+	clauses := s.elementsMinimum(elements[0], 1)
+	if !clauses[0].isVariable() {
+		s.malformedError()
+	}
+
+	// The handler escapes from body with the raised object.
+	raised := false
+	continuation := NewContinuation()
+	handler := NewSubroutine(func(arguments Object) Object {
+		raised = true
+		return continuation.Invoke(arguments)
+	})
+	result := continuation.catch(func() Object {
+		return withExceptionHandler(handler, func() Object {
+			return trampoline(evalBody(elements[1:]))
+		})
+	})
+	if !raised {
+		return result
+	}
+
+	// Clauses are evaluated after the handler is uninstalled, so that the
+	// object is re-raised to the outer handler when no clause is selected.
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+	frame.define(clauses[0].(*Variable).identifier, result)
+	selected := false
+	clauseResult := frame.call(func() (object Object) {
+		if len(clauses) > 1 {
+			object, selected = s.evalClauses(clauses[1:])
 		}
+		return
+	})
+	if selected {
+		return clauseResult
 	}
-	return undef
+	return raise(result, true)
 }
 
 func lambdaSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
+	closure := NewClosure(arguments.Parent(), running.environment)
 
 	elements := s.elementsMinimum(arguments, 1)
-	variables := s.elementsMinimum(elements[0], 0)
+	variables, rest := s.parameters(elements[0])
 
 	// generate function
 	closure.function = func(givenArguments Object) Object {
-		// assert given arguments
+		// define arguments to a new frame
+		// all arguments are evaluated before binding any of them
 		givenElements := s.elementsMinimum(givenArguments, 0)
-		if len(variables) != len(givenElements) {
-			compileError("wrong number of arguments: requires %d, but got %d", len(variables), len(givenElements))
-		}
-
-		// define arguments to local scope
-		for index, variable := range variables {
-			object := givenElements[index].Eval()
-			if variable.isVariable() {
-				closure.localBinding[variable.(*Variable).identifier] = object
-			}
-		}
+		frame := NewEnvironment(closure.environment, arguments.Parent(), 0)
+		s.bindParameters(frame, variables, rest, evaledObjects(givenElements), givenArguments)
 
 		// returns last eval result
-		lastResult := undef
-		for _, element := range elements[1:] {
-			lastResult = element.Eval()
-		}
-		return lastResult
+		return frame.evalBody(elements[1:])
 	}
 	return closure
 }
 
-func doSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
+// Bind the objects to the required parameters and the rest parameter
+// in the frame. The arguments are used for the arity error.
+func (s *Syntax) bindParameters(frame *Environment, variables []Object, rest *Variable, objects []Object, arguments Object) {
+	if rest != nil && len(variables) > len(objects) {
+		arityError(arguments, "wrong number of arguments: requires at least %d, but got %d", len(variables), len(objects))
+	} else if rest == nil && len(variables) != len(objects) {
+		arityError(arguments, "wrong number of arguments: requires %d, but got %d", len(variables), len(objects))
+	}
+
+	for index, variable := range variables {
+		if variable.isVariable() {
+			frame.define(variable.(*Variable).identifier, objects[index])
+		}
+	}
+	if rest != nil {
+		frame.define(rest.identifier, NewList(nil, objects[len(variables):]...))
+	}
+}
+
+// Returns the required parameters and the rest parameter of lambda list,
+// which is (a b), (a b . rest) or rest.
+func (s *Syntax) parameters(list Object) ([]Object, *Variable) {
+	if list.isVariable() {
+		return []Object{}, list.(*Variable)
+	}
+
+	variables := []Object{}
+	tail := list
+	if list.isApplication() {
+		variables = append(variables, list.(*Application).procedure)
+		tail = list.(*Application).arguments
+	}
+	for tail.isPair() {
+		variables = append(variables, tail.(*Pair).Car)
+		tail = tail.(*Pair).Cdr
+	}
+	if tail.isVariable() {
+		return variables, tail.(*Variable)
+	} else if !tail.isNull() {
+		s.malformedError()
+	}
+	return variables, nil
+}
 
+// (do ((variable init [step]) ...) (test expression ...) body ...)
+// Each iteration binds the variables in a new frame, so that closures
+// created in the body capture the variables of the iteration.
+func doSyntax(s *Syntax, arguments Object) Object {
 	// Parse iterator list and define first variable
 	elements := s.elementsMinimum(arguments, 2)
 	iteratorBodies := s.elementsMinimum(elements[0], 0)
+	iterators := [][]Object{}
 
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
 	for _, iteratorBody := range iteratorBodies {
 		iteratorElements := s.elementsMinimum(iteratorBody, 2)
 		if len(iteratorElements) > 3 {
-			compileError("bad update expr in %s: %s", s.Bounder(), s.Bounder().Parent())
+			compileError(s.Bounder().Parent(), "bad update expr in %s: %s", s.Bounder(), s.Bounder().Parent())
 		}
+		iterators = append(iterators, iteratorElements)
 
 		variable := iteratorElements[0]
 		value := iteratorElements[1]
 		if variable.isVariable() {
-			closure.localBinding[variable.(*Variable).identifier] = value.Eval()
+			frame.define(variable.(*Variable).identifier, value.Eval())
 		}
 	}
 
@@ -282,54 +679,228 @@ func doSyntax(s *Syntax, arguments Object) Object {
 	continueElements := elements[2:]
 
 	for {
-		testResult := testElements[0].Eval()
+		testResult := frame.eval(testElements[0])
 		if !testResult.isBoolean() || testResult.(*Boolean).value == true {
-			for _, element := range testElements[1:] {
-				testResult = element.Eval()
+			if len(testElements) == 1 {
+				return testResult
 			}
-			return testResult
+			return frame.evalBody(testElements[1:])
 		} else {
 			// eval continueBody
 			for _, element := range continueElements {
-				element.Eval()
+				frame.eval(element)
 			}
 
-			// update iterators
-			for _, iteratorBody := range iteratorBodies {
-				iteratorElements := s.elementsMinimum(iteratorBody, 2)
-
+			// update iterators in the next frame
+			next := NewEnvironment(running.environment, arguments.Parent(), 0)
+			for _, iteratorElements := range iterators {
+				variable := iteratorElements[0]
+				if !variable.isVariable() {
+					continue
+				}
+				identifier := variable.(*Variable).identifier
 				if len(iteratorElements) == 3 {
-					variable := iteratorElements[0]
-					if variable.isVariable() {
-						closure.localBinding[variable.(*Variable).identifier] = iteratorElements[2].Eval()
-					}
+					next.define(identifier, frame.eval(iteratorElements[2]))
+				} else {
+					next.define(identifier, frame.get(identifier))
 				}
 			}
+			frame = next
 		}
 	}
-	return undef
 }
 
+// (let ((variable init) ...) body ...)
+// All inits are evaluated in the outer scope, and then the variables
+// are bound in the new frame of the body.
 func letSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
+	elements := s.elementsMinimum(arguments, 1)
+	if elements[0].isVariable() {
+		return s.namedLet(arguments.Parent(), elements)
+	}
+
+	variables, inits := s.letBindings(elements[0])
+	objects := evaledObjects(inits)
+
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+	for index, variable := range variables {
+		frame.define(variable.(*Variable).identifier, objects[index])
+	}
+	return frame.evalBody(elements[1:])
+}
 
+// (let name ((variable init) ...) body ...)
+// The body is the procedure bound to the name, which is visible only in
+// the body. The procedure is called with inits evaluated in the outer scope.
+func (s *Syntax) namedLet(form Object, elements []Object) Object {
+	if len(elements) < 2 {
+		s.malformedError()
+	}
+	variables, inits := s.letBindings(elements[1])
+
+	scope := NewEnvironment(running.environment, form, 0)
+	procedure := NewClosure(form, scope)
+	procedure.function = func(givenArguments Object) Object {
+		givenElements := s.elementsMinimum(givenArguments, 0)
+		frame := NewEnvironment(scope, form, 1)
+		s.bindParameters(frame, variables, nil, evaledObjects(givenElements), givenArguments)
+		return frame.evalBody(elements[2:])
+	}
+	scope.define(elements[0].(*Variable).identifier, procedure)
+
+	application := NewApplication(form)
+	application.procedure = procedure
+	application.arguments = NewList(nil, inits...)
+	return evalTail(application)
+}
+
+// (let* ((variable init) ...) body ...)
+// Each init is evaluated in the frame of the preceding variables.
+func letStarSyntax(s *Syntax, arguments Object) Object {
 	elements := s.elementsMinimum(arguments, 1)
-	argumentElements := s.elementsMinimum(elements[0], 0)
+	variables, inits := s.letBindings(elements[0])
+
+	frame := running.environment
+	for index, variable := range variables {
+		object := frame.eval(inits[index])
+		frame = NewEnvironment(frame, arguments.Parent(), index)
+		frame.define(variable.(*Variable).identifier, object)
+	}
+	return NewEnvironment(frame, arguments.Parent(), len(variables)).evalBody(elements[1:])
+}
 
-	// define arguments to local scope
-	for _, argumentElement := range argumentElements {
-		variableElements := s.elementsMinimum(argumentElement, 2)
-		variable := variableElements[0]
+// (letrec ((variable init) ...) body ...)
+// All inits are evaluated in the frame of the variables, and then
+// the variables are bound. So inits can refer to the variables
+// only in procedures, such as mutually recursive ones.
+func letrecSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+	variables, inits := s.letBindings(elements[0])
 
-		if variable.isVariable() {
-			closure.localBinding[variable.(*Variable).identifier] = variableElements[1].Eval()
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+	objects := []Object{}
+	for _, init := range inits {
+		objects = append(objects, frame.eval(init))
+	}
+	for index, variable := range variables {
+		frame.define(variable.(*Variable).identifier, objects[index])
+	}
+	return frame.evalBody(elements[1:])
+}
+
+// (letrec* ((variable init) ...) body ...)
+// It is like letrec, but each variable is bound just after its init
+// is evaluated, so that following inits can refer to it.
+func letrecStarSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+	variables, inits := s.letBindings(elements[0])
+
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+	for index, variable := range variables {
+		frame.define(variable.(*Variable).identifier, frame.eval(inits[index]))
+	}
+	return frame.evalBody(elements[1:])
+}
+
+// Returns variables and inits of bindings, which are ((variable init) ...).
+func (s *Syntax) letBindings(bindings Object) ([]Object, []Object) {
+	variables, inits := []Object{}, []Object{}
+	for _, binding := range s.elementsMinimum(bindings, 0) {
+		bindingElements := s.elementsMinimum(binding, 2)
+		if len(bindingElements) != 2 || !bindingElements[0].isVariable() {
+			s.malformedError()
 		}
+		variables = append(variables, bindingElements[0])
+		inits = append(inits, bindingElements[1])
 	}
+	return variables, inits
+}
+
+// (let-values ((formals init) ...) body ...)
+// Formals are bound to the values of init, like parameters of lambda.
+func letValuesSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+	formals, inits := s.letValuesBindings(elements[0])
+	objects := evaledObjects(inits)
 
-	// eval body
-	lastResult := undef
-	for _, element := range elements[1:] {
-		lastResult = element.Eval()
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+	for index, formal := range formals {
+		variables, rest := s.parameters(formal)
+		s.bindParameters(frame, variables, rest, valuesToObjects(objects[index]), formal)
 	}
-	return lastResult
+	return frame.evalBody(elements[1:])
+}
+
+// (let*-values ((formals init) ...) body ...)
+// Each init is evaluated in the frame of the preceding formals.
+func letStarValuesSyntax(s *Syntax, arguments Object) Object {
+	elements := s.elementsMinimum(arguments, 1)
+	formals, inits := s.letValuesBindings(elements[0])
+
+	frame := running.environment
+	for index, formal := range formals {
+		objects := valuesToObjects(frame.eval(inits[index]))
+		frame = NewEnvironment(frame, arguments.Parent(), index)
+		variables, rest := s.parameters(formal)
+		s.bindParameters(frame, variables, rest, objects, formal)
+	}
+	return NewEnvironment(frame, arguments.Parent(), len(formals)).evalBody(elements[1:])
+}
+
+// Returns formals and inits of bindings, which are ((formals init) ...).
+func (s *Syntax) letValuesBindings(bindings Object) ([]Object, []Object) {
+	formals, inits := []Object{}, []Object{}
+	for _, binding := range s.elementsMinimum(bindings, 0) {
+		if !binding.isApplication() {
+			s.malformedError()
+		}
+		// The formals such as (a b) are parsed as the procedure of application.
+		bindingElements := s.elementsMinimum(binding.(*Application).arguments, 1)
+		if len(bindingElements) != 1 {
+			s.malformedError()
+		}
+		formals = append(formals, binding.(*Application).procedure)
+		inits = append(inits, bindingElements[0])
+	}
+	return formals, inits
+}
+
+// (let-syntax ((keyword transformer) ...) body ...)
+// Macros are bound in the new scope of the body. Free identifiers in
+// templates are resolved in the enclosing scope, so the frame does not
+// enclose the transformers.
+func letSyntaxSyntax(s *Syntax, arguments Object) Object {
+	return s.evalSyntaxBindings(arguments, false)
+}
+
+// (letrec-syntax ((keyword transformer) ...) body ...)
+// Free identifiers in templates are resolved in the new scope,
+// so that macros can refer to themselves and each other.
+func letrecSyntaxSyntax(s *Syntax, arguments Object) Object {
+	return s.evalSyntaxBindings(arguments, true)
+}
+
+// Bind macros of let-syntax or letrec-syntax in the new frame, and evaluate the body.
+func (s *Syntax) evalSyntaxBindings(arguments Object, recursive bool) Object {
+	frame := NewEnvironment(running.environment, arguments.Parent(), 0)
+
+	// Bindings are not converted to lists, which would change the parents
+	// of transformers, because the frame encloses them by their parents.
+	elements := s.elementsMinimum(arguments, 1)
+	bindings, ok := formElements(elements[0])
+	if !ok {
+		s.malformedError()
+	}
+	if !recursive {
+		frame.outside = elements[0]
+	}
+	for _, binding := range bindings {
+		bindingElements, ok := formElements(binding)
+		if !ok || len(bindingElements) != 2 || !bindingElements[0].isVariable() {
+			s.malformedError()
+		}
+		frame.define(bindingElements[0].(*Variable).identifier, evalTransformer(s.Bounder().Parent(), bindingElements[1]))
+	}
+
+	return frame.evalBody(elements[1:])
 }
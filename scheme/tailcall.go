@@ -0,0 +1,54 @@
+// TailCall is an application which is deferred from a tail position.
+// A syntax form or a closure returns TailCall instead of evaluating its
+// last expression, and the nearest Application.Eval() evaluates it in a
+// loop. So a tail call does not consume Go's stack.
+
+package scheme
+
+// TailCall is a struction for deferred application.
+type TailCall struct {
+	ObjectBase
+	application *Application
+	environment *Environment
+}
+
+// Eval is TailCall's eval IF.
+func (t *TailCall) Eval() Object {
+	return trampoline(t)
+}
+
+func (t *TailCall) String() string {
+	return t.application.String()
+}
+
+// Evaluate an object in tail position.
+// Application is not evaluated here but wrapped with TailCall,
+// with the current environment where it is evaluated.
+func evalTail(object Object) Object {
+	if object.isApplication() {
+		return &TailCall{ObjectBase: ObjectBase{parent: object.Parent()}, application: object.(*Application), environment: running.environment}
+	}
+	return object.Eval()
+}
+
+// Defer the application of the procedure to objects which are already
+// evaluated, such as the consumer of call-with-values in tail position.
+func deferProcedure(procedure Object, objects ...Object) Object {
+	if !procedure.isProcedure() {
+		typeError(procedure, "procedure required, but got %s", procedure)
+	}
+	application := NewApplication(nil)
+	application.procedure, application.arguments = procedure, NewList(nil, objects...)
+	return &TailCall{application: application, environment: running.environment}
+}
+
+// Evaluate deferred applications until the result is not TailCall.
+func trampoline(object Object) Object {
+	for {
+		tailCall, ok := object.(*TailCall)
+		if !ok {
+			return object
+		}
+		object = tailCall.environment.call(tailCall.application.applyProcedure)
+	}
+}
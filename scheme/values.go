@@ -0,0 +1,42 @@
+// Values is a type for multiple values, which are returned by values
+// procedure and integer division such as floor/.
+// A single value is not wrapped, so that it can be used as usual object.
+
+package scheme
+
+import "strings"
+
+// Values is a struction for multiple values.
+type Values struct {
+	ObjectBase
+	objects []Object
+}
+
+// NewValues is a function for definition of multiple values.
+func NewValues(objects ...Object) Object {
+	if len(objects) == 1 {
+		return objects[0]
+	}
+	return &Values{objects: objects}
+}
+
+// Eval is Values's eval IF.
+func (v *Values) Eval() Object {
+	return v
+}
+
+func (v *Values) String() string {
+	texts := []string{}
+	for _, object := range v.objects {
+		texts = append(texts, object.String())
+	}
+	return strings.Join(texts, " ")
+}
+
+// Returns values as slice, which has one element for a single value.
+func valuesToObjects(object Object) []Object {
+	if values, ok := object.(*Values); ok {
+		return values.objects
+	}
+	return []Object{object}
+}
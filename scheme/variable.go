@@ -1,7 +1,8 @@
 // Scheme's identifier is classfied to a symbol or a variable.
 // And this type own a role to express a variable.
 // Variable itself does not have a value for identifier,
-// interpreter searched it from its code block scope by Variable's identifier.
+// interpreter searches it from the current environment by Variable's identifier.
+// Variable renamed by macro expansion has the original variable in the template.
 
 package scheme
 
@@ -9,6 +10,9 @@ package scheme
 type Variable struct {
 	ObjectBase
 	identifier string
+	original   *Variable
+	address    *lexicalAddress // the address resolved by analysis
+	cell       *cell           // the top level binding resolved by analysis
 }
 
 // NewVariable is a function for scheme variable object.
@@ -21,15 +25,31 @@ func NewVariable(identifier string, parent Object) *Variable {
 
 // Eval is variable's eval IF.
 func (v *Variable) Eval() Object {
-	object := v.boundedObject(v.identifier)
+	return v.evalIn(running.environment)
+}
+
+// Returns the bound object in the environment, or raise error when
+// the variable is not bound.
+func (v *Variable) evalIn(environment *Environment) Object {
+	object := environment.boundedObject(v)
 	if object == nil {
-		runtimeError("Unbound variable: %s", v.identifier)
+		unboundVariableError(v)
 	}
 	object.setBounder(v)
 	return object
 }
 
+// Returns the bound object in the current environment, or nil when
+// the variable is not bound.
+func (v *Variable) resolve() Object {
+	return running.environment.boundedObject(v)
+}
+
+// String returns the identifier written in source code.
 func (v *Variable) String() string {
+	if v.original != nil {
+		return v.original.String()
+	}
 	return v.identifier
 }
 
@@ -0,0 +1,42 @@
+// Vector is a type for scheme vector object, which is expressed
+// like #(1 2 3). Its elements are accessed by index in constant time.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Vector is a struction for scheme vector object.
+type Vector struct {
+	ObjectBase
+	elements []Object
+}
+
+// NewVector is a function for definition a new Vector object.
+func NewVector(elements []Object, options ...Object) *Vector {
+	vector := &Vector{elements: elements}
+	if len(options) > 0 {
+		vector.parent = options[0]
+	}
+	return vector
+}
+
+// Eval is vector's eval IF.
+// Vector literal is self-evaluating.
+func (v *Vector) Eval() Object {
+	return v
+}
+
+func (v *Vector) String() string {
+	texts := []string{}
+	for _, element := range v.elements {
+		texts = append(texts, element.String())
+	}
+	return fmt.Sprintf("#(%s)", strings.Join(texts, " "))
+}
+
+func (v *Vector) isVector() bool {
+	return true
+}
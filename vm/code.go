@@ -0,0 +1,146 @@
+// Code is a compiled procedure body, which is a sequence of instructions
+// and constants referred by them.
+// Each instruction has an opcode and two operands, whose meanings depend on
+// the opcode. Values, variables and environments are opaque to the machine,
+// and they are handled by Host of the language.
+
+package vm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Opcode is a type for operation of instruction.
+type Opcode byte
+
+// Opcodes of instructions, where "top" is the value on top of the stack.
+const (
+	// Push the constant A.
+	Constant Opcode = iota
+	// Push the value of the variable in the constant A.
+	Load
+	// Assign top to the variable in the constant A, keeping top.
+	Store
+	// Define the variable in the constant A by top, popping it.
+	Define
+	// Pop top.
+	Pop
+	// Push top again.
+	Dup
+	// Jump to A.
+	Jump
+	// Pop top, and jump to A when it is false.
+	JumpIfFalse
+	// Pop top, and jump to A when it is not false.
+	JumpIfTrue
+	// Push the procedure of the lambda in the constant A.
+	Closure
+	// Pop B values, and bind them in a new frame laid out by the constant A.
+	Enter
+	// Leave A frames, and return to their parent.
+	Leave
+	// Record the application in the constant A as the call site.
+	Site
+	// Replace the call site of this frame by the application in the constant A.
+	TailSite
+	// Evaluate the application in the constant A instead, when top is syntax.
+	// Then jump to B.
+	Check
+	// Same as Check, but return from this frame.
+	TailCheck
+	// Call the procedure under A arguments on the stack.
+	Call
+	// Call the procedure under A arguments on the stack in place of this frame.
+	TailCall
+	// Return top from this frame.
+	Return
+	// Push the result of the expression in the constant A, which is not compiled.
+	Eval
+	// Return the result of the expression in the constant A, which is not compiled.
+	TailEval
+)
+
+var opcodeNames = []string{
+	"constant", "load", "store", "define", "pop", "dup", "jump", "jump-if-false", "jump-if-true",
+	"closure", "enter", "leave", "site", "tail-site", "check", "tail-check",
+	"call", "tail-call", "return", "eval", "tail-eval",
+}
+
+func (o Opcode) String() string {
+	if int(o) < len(opcodeNames) {
+		return opcodeNames[o]
+	}
+	return fmt.Sprintf("opcode(%d)", o)
+}
+
+// Instruction is a struction for an operation and its operands.
+type Instruction struct {
+	Opcode Opcode
+	A      int
+	B      int
+}
+
+// Code is a struction for compiled procedure body.
+type Code struct {
+	Instructions []Instruction
+	Constants    []Value
+}
+
+// NewCode is a function for definition a new empty Code.
+func NewCode() *Code {
+	return &Code{}
+}
+
+// Emit appends the instruction, and returns its address.
+func (c *Code) Emit(opcode Opcode, operands ...int) int {
+	instruction := Instruction{Opcode: opcode}
+	if len(operands) > 0 {
+		instruction.A = operands[0]
+	}
+	if len(operands) > 1 {
+		instruction.B = operands[1]
+	}
+	c.Instructions = append(c.Instructions, instruction)
+	return len(c.Instructions) - 1
+}
+
+// AddConstant appends the value to constants, and returns its index.
+func (c *Code) AddConstant(value Value) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// Patch sets the jump target of the instruction at the address
+// to the next address.
+func (c *Code) Patch(address int) {
+	switch c.Instructions[address].Opcode {
+	case Check:
+		c.Instructions[address].B = len(c.Instructions)
+	default:
+		c.Instructions[address].A = len(c.Instructions)
+	}
+}
+
+// Next returns the address of the instruction which is emitted next.
+func (c *Code) Next() int {
+	return len(c.Instructions)
+}
+
+// String returns the disassembled instructions.
+func (c *Code) String() string {
+	lines := []string{}
+	for address, instruction := range c.Instructions {
+		line := fmt.Sprintf("%4d  %-13s %d", address, instruction.Opcode, instruction.A)
+		switch instruction.Opcode {
+		case Enter, Check:
+			line += fmt.Sprintf(" %d", instruction.B)
+		}
+		switch instruction.Opcode {
+		case Constant, Load, Store, Define, Site, TailSite, Check, TailCheck, Eval, TailEval:
+			line += fmt.Sprintf("\t; %v", c.Constants[instruction.A])
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,210 @@
+// Machine is a stack based virtual machine, which runs Code.
+// A call of compiled procedure pushes a frame of the machine instead of
+// calling Go function, and a call in tail position replaces the current
+// frame. So compiled procedures call each other without consuming Go's stack.
+// Other procedures, such as builtin functions, are called through Host.
+
+package vm
+
+// Value is a value of the language, such as number and procedure.
+type Value interface{}
+
+// Environment is a frame of variable bindings of the language.
+type Environment interface{}
+
+// Host is an interface of the language which runs on the machine.
+// Slices of arguments passed to Host are valid only until it returns.
+type Host interface {
+	// Returns the value of the variable.
+	Load(environment Environment, variable Value) Value
+	// Assign the value to the variable.
+	Store(environment Environment, variable Value, value Value)
+	// Define the variable in the environment.
+	Define(environment Environment, variable Value, value Value)
+	// Returns true when the value is false as condition.
+	IsFalse(value Value) bool
+	// Returns the procedure of the lambda closed in the environment.
+	Closure(lambda Value, environment Environment) Value
+	// Returns a new frame in the environment, laid out by the scope.
+	Extend(environment Environment, scope Value, values []Value) Environment
+	// Returns the parent of the environment.
+	Parent(environment Environment) Environment
+	// Push the call site, or replace the last one.
+	Site(site Value, replace bool)
+	// Pop the last call site.
+	PopSite()
+	// Returns true when the procedure is a syntax, which is not applied
+	// to evaluated arguments.
+	IsSyntax(procedure Value) bool
+	// Returns the code of the compiled procedure and its frame for
+	// arguments, or false when the procedure is not compiled.
+	Enter(procedure Value, arguments []Value) (*Code, Environment, bool)
+	// Apply the procedure which is not compiled. When deferrable is true,
+	// the result may be deferred to be evaluated by the caller of the machine.
+	Apply(procedure Value, arguments []Value, environment Environment, deferrable bool) Value
+	// Evaluate the expression which is not compiled. When deferrable is true,
+	// the result may be deferred to be evaluated by the caller of the machine.
+	Eval(expression Value, environment Environment, deferrable bool) Value
+}
+
+// Frame of the machine, which is created by each call of compiled procedure.
+type frame struct {
+	code        *Code
+	pc          int
+	environment Environment
+	base        int  // height of the stack when this frame is entered
+	site        bool // whether this frame owns the last call site
+}
+
+// Machine is a struction for virtual machine.
+type Machine struct {
+	host   Host
+	stack  []Value
+	frames []frame // callers of the current frame
+}
+
+// NewMachine is a function for definition a new Machine.
+func NewMachine(host Host) *Machine {
+	return &Machine{host: host, stack: make([]Value, 0, 64)}
+}
+
+// Run the code in the environment, and returns its result. The machine
+// may be run again by the host while it is running, such as a compiled
+// closure called from a builtin procedure. Frames and values of the outer
+// run are kept under the inner one, and they are restored when an error
+// or a continuation escapes from the inner run.
+func (m *Machine) Run(code *Code, environment Environment) Value {
+	depth, height := len(m.frames), len(m.stack)
+	defer func() {
+		m.frames, m.stack = m.frames[:depth], m.stack[:height]
+	}()
+	current := frame{code: code, environment: environment, base: height}
+	for {
+		instruction := current.code.Instructions[current.pc]
+		current.pc++
+
+		switch instruction.Opcode {
+		case Constant:
+			m.push(current.code.Constants[instruction.A])
+		case Load:
+			m.push(m.host.Load(current.environment, current.code.Constants[instruction.A]))
+		case Store:
+			m.host.Store(current.environment, current.code.Constants[instruction.A], m.top())
+		case Define:
+			m.host.Define(current.environment, current.code.Constants[instruction.A], m.pop())
+		case Pop:
+			m.pop()
+		case Dup:
+			m.push(m.top())
+		case Jump:
+			current.pc = instruction.A
+		case JumpIfFalse:
+			if m.host.IsFalse(m.pop()) {
+				current.pc = instruction.A
+			}
+		case JumpIfTrue:
+			if !m.host.IsFalse(m.pop()) {
+				current.pc = instruction.A
+			}
+		case Closure:
+			m.push(m.host.Closure(current.code.Constants[instruction.A], current.environment))
+		case Enter:
+			values := m.stack[len(m.stack)-instruction.B:]
+			current.environment = m.host.Extend(current.environment, current.code.Constants[instruction.A], values)
+			m.stack = m.stack[:len(m.stack)-instruction.B]
+		case Leave:
+			for i := 0; i < instruction.A; i++ {
+				current.environment = m.host.Parent(current.environment)
+			}
+		case Site:
+			m.host.Site(current.code.Constants[instruction.A], false)
+		case TailSite:
+			m.host.Site(current.code.Constants[instruction.A], current.site)
+			current.site = true
+		case Check, TailCheck:
+			if !m.host.IsSyntax(m.top()) {
+				continue
+			}
+			m.pop()
+			value := m.host.Eval(current.code.Constants[instruction.A], current.environment, false)
+			if instruction.Opcode == TailCheck {
+				if m.leave(&current, value, depth) {
+					return value
+				}
+				continue
+			}
+			m.host.PopSite()
+			m.push(value)
+			current.pc = instruction.B
+		case Call, TailCall:
+			tail := instruction.Opcode == TailCall
+			bottom := len(m.stack) - instruction.A - 1
+			procedure, arguments := m.stack[bottom], m.stack[bottom+1:]
+			if code, environment, ok := m.host.Enter(procedure, arguments); ok {
+				m.stack = m.stack[:bottom]
+				if tail {
+					m.stack = m.stack[:current.base]
+					current = frame{code: code, environment: environment, base: current.base, site: current.site}
+				} else {
+					m.frames = append(m.frames, current)
+					current = frame{code: code, environment: environment, base: bottom, site: true}
+				}
+				continue
+			}
+
+			value := m.host.Apply(procedure, arguments, current.environment, tail && len(m.frames) == depth)
+			m.stack = m.stack[:bottom]
+			if tail {
+				if m.leave(&current, value, depth) {
+					return value
+				}
+				continue
+			}
+			m.host.PopSite()
+			m.push(value)
+		case Return:
+			value := m.pop()
+			if m.leave(&current, value, depth) {
+				return value
+			}
+		case Eval:
+			m.push(m.host.Eval(current.code.Constants[instruction.A], current.environment, false))
+		case TailEval:
+			value := m.host.Eval(current.code.Constants[instruction.A], current.environment, len(m.frames) == depth)
+			if m.leave(&current, value, depth) {
+				return value
+			}
+		}
+	}
+}
+
+// Return the value from the current frame to its caller, and returns true
+// when the current frame is the first one of the run, whose callers are
+// the depth frames of outer runs.
+func (m *Machine) leave(current *frame, value Value, depth int) bool {
+	if current.site {
+		m.host.PopSite()
+	}
+	m.stack = m.stack[:current.base]
+	if len(m.frames) == depth {
+		return true
+	}
+	*current = m.frames[len(m.frames)-1]
+	m.frames = m.frames[:len(m.frames)-1]
+	m.push(value)
+	return false
+}
+
+func (m *Machine) push(value Value) {
+	m.stack = append(m.stack, value)
+}
+
+func (m *Machine) pop() Value {
+	value := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return value
+}
+
+func (m *Machine) top() Value {
+	return m.stack[len(m.stack)-1]
+}